@@ -0,0 +1,159 @@
+package build
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+	"golang.org/x/mod/modfile"
+)
+
+var affectedBase = flag.String("affected", "", "restrict runall, test-go and lint-go to workspace modules affected by changes since the `git ref`")
+
+// skipIfNotAffected skips the task if -affected is set and none of the modules containing
+// dirs are affected.
+func skipIfNotAffected(a *goyek.A, dirs ...string) {
+	a.Helper()
+	if *affectedBase == "" {
+		return
+	}
+	affected := affectedModules(a, *affectedBase)
+	if a.Failed() {
+		return
+	}
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if slices.ContainsFunc(affected, func(m workspaceModule) bool { return m.Dir == abs }) {
+			return
+		}
+	}
+	a.Skipf("no affected modules since %s", *affectedBase)
+}
+
+// affectedModules returns the workspace modules that contain files changed since the merge
+// base with base, or that transitively depend on a module that does.
+func affectedModules(a *goyek.A, base string) []workspaceModule {
+	a.Helper()
+	mods := workspaceModules(a)
+	if len(mods) == 0 {
+		return nil
+	}
+
+	var top bytes.Buffer
+	if !cmd.Exec(a, "git rev-parse --show-toplevel", cmd.Stdout(&top)) {
+		return nil
+	}
+	gitRoot := strings.TrimSpace(top.String())
+
+	var mergeBase bytes.Buffer
+	if !cmd.Exec(a, fmt.Sprintf("git merge-base %s HEAD", base), cmd.Stdout(&mergeBase)) {
+		return nil
+	}
+
+	// Compare the working tree to include uncommitted changes, and add untracked files. Paths
+	// are separated by NUL since they may contain spaces.
+	var changed bytes.Buffer
+	if !cmd.Exec(a, "git diff -z --name-only "+strings.TrimSpace(mergeBase.String()), cmd.Stdout(&changed), cmd.Dir(gitRoot)) {
+		return nil
+	}
+	if !cmd.Exec(a, "git ls-files -z --others --exclude-standard", cmd.Stdout(&changed), cmd.Dir(gitRoot)) {
+		return nil
+	}
+
+	var changedMods []string
+	for f := range strings.SplitSeq(changed.String(), "\x00") {
+		if f == "" {
+			continue
+		}
+		path := filepath.Join(gitRoot, filepath.FromSlash(f))
+		if filepath.Base(path) == "go.work" || filepath.Base(path) == "go.work.sum" {
+			// Workspace changes can affect the build of any module.
+			return mods
+		}
+		if m := containingModule(mods, path); m != nil {
+			changedMods = append(changedMods, m.Path)
+		}
+	}
+
+	requires := map[string][]string{}
+	for _, m := range mods {
+		requires[m.Path] = moduleRequires(m)
+	}
+	affected := withDependents(changedMods, requires)
+
+	var res []workspaceModule
+	for _, m := range mods {
+		if affected[m.Path] {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// withDependents returns the modules in changed and the modules that transitively require
+// any of them, given the module paths required by each module.
+func withDependents(changed []string, requires map[string][]string) map[string]bool {
+	dependents := map[string][]string{}
+	for m, reqs := range requires {
+		for _, req := range reqs {
+			dependents[req] = append(dependents[req], m)
+		}
+	}
+
+	affected := map[string]bool{}
+	queue := slices.Clone(changed)
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if affected[p] {
+			continue
+		}
+		affected[p] = true
+		queue = append(queue, dependents[p]...)
+	}
+	return affected
+}
+
+// containingModule returns the module with the deepest directory containing path.
+func containingModule(mods []workspaceModule, path string) *workspaceModule {
+	var res *workspaceModule
+	for i, m := range mods {
+		rel, err := filepath.Rel(m.Dir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if res == nil || len(m.Dir) > len(res.Dir) {
+			res = &mods[i]
+		}
+	}
+	return res
+}
+
+// moduleRequires returns the module paths required by the module's go.mod.
+func moduleRequires(m workspaceModule) []string {
+	if m.GoMod == "" {
+		return nil
+	}
+	b, err := os.ReadFile(m.GoMod)
+	if err != nil {
+		return nil
+	}
+	f, err := modfile.ParseLax(m.GoMod, b, nil)
+	if err != nil {
+		return nil
+	}
+	reqs := make([]string, 0, len(f.Require))
+	for _, r := range f.Require {
+		reqs = append(reqs, r.Mod.Path)
+	}
+	return reqs
+}
@@ -0,0 +1,106 @@
+package build
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/goyek/goyek/v3"
+)
+
+func TestContainingModule(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	mods := []workspaceModule{
+		{Path: "example.com/repo", Dir: root},
+		{Path: "example.com/repo/sub", Dir: filepath.Join(root, "sub")},
+		{Path: "example.com/repo/sub/nested", Dir: filepath.Join(root, "sub", "nested")},
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "main.go", want: "example.com/repo"},
+		{path: "sub/a.go", want: "example.com/repo/sub"},
+		{path: "sub/has space/a b.go", want: "example.com/repo/sub"},
+		{path: "sub/nested/go.mod", want: "example.com/repo/sub/nested"},
+		{path: "sub2/a.go", want: "example.com/repo"},
+		{path: "../other/a.go", want: ""},
+	}
+	for _, tc := range tests {
+		got := ""
+		if m := containingModule(mods, filepath.Join(root, filepath.FromSlash(tc.path))); m != nil {
+			got = m.Path
+		}
+		if got != tc.want {
+			t.Errorf("containingModule(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestWithDependents(t *testing.T) {
+	requires := map[string][]string{
+		"app":    {"lib", "util", "github.com/x/y"},
+		"cli":    {"util"},
+		"lib":    {"util"},
+		"util":   nil,
+		"other":  nil,
+		"cycleA": {"cycleB"},
+		"cycleB": {"cycleA"},
+	}
+	tests := []struct {
+		name    string
+		changed []string
+		want    []string
+	}{
+		{name: "none"},
+		{name: "leaf", changed: []string{"app"}, want: []string{"app"}},
+		{name: "shared", changed: []string{"util"}, want: []string{"app", "cli", "lib", "util"}},
+		{name: "middle", changed: []string{"lib"}, want: []string{"app", "lib"}},
+		{name: "duplicates", changed: []string{"lib", "lib", "other"}, want: []string{"app", "lib", "other"}},
+		{name: "cycle", changed: []string{"cycleA"}, want: []string{"cycleA", "cycleB"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for m := range withDependents(tc.changed, requires) {
+				got = append(got, m)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("withDependents(%v) = %v, want %v", tc.changed, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAffectedModulesPathsWithSpaces(t *testing.T) {
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOWORK", "")
+	t.Setenv("GOPROXY", "off")
+	dir := t.TempDir()
+	initGitRepo(t, dir, map[string]string{
+		"go.work":    "go 1.25\n\nuse (\n\t.\n\t./a\n\t./b\n)\n",
+		"go.mod":     "module example.com/root\n\ngo 1.25\n",
+		"a/go.mod":   "module example.com/root/a\n\ngo 1.25\n",
+		"b/go.mod":   "module example.com/root/b\n\ngo 1.25\n",
+		"a/x b/a.go": "package x\n",
+	})
+	// Split on spaces, the changed and untracked paths would look like files in module b.
+	writeFiles(t, dir, map[string]string{"a/x b/a.go": "package x\n\n// changed\n", "a/y b/new.go": "package y\n"})
+	t.Chdir(dir)
+
+	var got []string
+	var out bytes.Buffer
+	res := goyek.NewRunner(func(a *goyek.A) {
+		for _, m := range affectedModules(a, "HEAD") {
+			got = append(got, m.Path)
+		}
+	})(goyek.Input{Output: &out})
+	if res.Status != goyek.StatusPassed {
+		t.Fatalf("status = %v, want passed, output:\n%s", res.Status, out.String())
+	}
+	if want := []string{"example.com/root/a"}; !slices.Equal(got, want) {
+		t.Errorf("affected = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,125 @@
+package build
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// LintAPI returns an Option to enable the lint-api task, which fails on incompatible changes
+// to the exported Go API since the last release tag of the module. It is meant for libraries,
+// applications generally do not need it.
+func LintAPI() Option {
+	return lintAPIOption{}
+}
+
+type lintAPIOption struct{}
+
+func (lintAPIOption) apply(c *config) {
+	c.lintAPI = true
+}
+
+// lintAPI fails if the exported API of the module in dir has incompatible changes compared
+// to its last release tag, unless the major version of the module path was bumped since.
+// Before v1, incompatible changes are allowed and only reported.
+func lintAPI(a *goyek.A, runApidiff string, dir string) {
+	a.Helper()
+	ctx := a.Context()
+
+	goMod := filepath.Join(dir, "go.mod")
+	b, err := os.ReadFile(goMod)
+	if err != nil {
+		a.Errorf("failed to read %s: %v", goMod, err)
+		return
+	}
+	modPath := modfile.ModulePath(b)
+
+	root, prefix := moduleTagPrefix(dir)
+	if root == "" {
+		a.Errorf("no repository root found for %s", dir)
+		return
+	}
+	v, err := gitVersion(ctx, dir, prefix)
+	if err != nil {
+		a.Error(err)
+		return
+	}
+	if v.Tag == "" {
+		a.Logf("%s: no release tag, skipping", modPath)
+		return
+	}
+	if v.Commits == 0 && !v.Dirty {
+		a.Logf("%s: unchanged since %s", modPath, v.Tag)
+		return
+	}
+	pathMajor := "v1"
+	if _, pm, ok := module.SplitPathVersion(modPath); ok && pm != "" {
+		pathMajor = module.PathMajorPrefix(pm)
+	}
+	major := semver.Major(v.Semver)
+	if major == "v0" {
+		major = "v1"
+	}
+	if major != pathMajor {
+		a.Logf("%s: major version bumped since %s, skipping", modPath, v.Tag)
+		return
+	}
+
+	tmp, err := os.MkdirTemp("", "lint-api")
+	if err != nil {
+		a.Errorf("failed to create temp directory: %v", err)
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	worktree := filepath.Join(tmp, "worktree")
+	if _, err := git(ctx, root, "worktree", "add", "--detach", worktree, v.Tag); err != nil {
+		a.Error(err)
+		return
+	}
+	defer func() {
+		if _, err := git(ctx, root, "worktree", "remove", "--force", worktree); err != nil {
+			a.Error(err)
+		}
+	}()
+
+	// The module directory relative to the repository root, for example "sub/".
+	relDir, err := git(ctx, dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		a.Error(err)
+		return
+	}
+	oldDir := filepath.Join(worktree, filepath.FromSlash(relDir))
+	oldExport := filepath.Join(tmp, "old.export")
+	newExport := filepath.Join(tmp, "new.export")
+	// The released module is resolved with its own go.mod requirements rather than the
+	// current workspace.
+	if !cmd.Exec(a, runApidiff+" -m -w "+oldExport+" "+modPath, cmd.Dir(oldDir), cmd.Env("GOWORK", "off")) {
+		return
+	}
+	if !cmd.Exec(a, runApidiff+" -m -w "+newExport+" "+modPath, cmd.Dir(dir)) {
+		return
+	}
+
+	var out bytes.Buffer
+	if !cmd.Exec(a, runApidiff+" -m -incompatible "+oldExport+" "+newExport, cmd.Dir(dir), cmd.Stdout(&out)) {
+		return
+	}
+	changes := strings.TrimSpace(out.String())
+	switch {
+	case changes == "":
+		a.Logf("%s: compatible with %s", modPath, v.Tag)
+	case semver.Major(v.Semver) == "v0":
+		a.Logf("%s: incompatible changes since %s, allowed before v1:\n%s", modPath, v.Tag, changes)
+	default:
+		a.Errorf("%s: incompatible changes since %s, revert them or bump the major version of the module:\n%s",
+			modPath, v.Tag, changes)
+	}
+}
@@ -13,6 +13,7 @@ require (
 	github.com/mattn/go-colorable v0.1.14 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-shellwords v1.0.12 // indirect
+	golang.org/x/mod v0.40.0 // indirect
 	golang.org/x/sys v0.35.0 // indirect
 )
 
@@ -7,6 +7,6 @@ import (
 )
 
 func main() {
-	build.DefineTasks()
+	build.DefineTasks(build.LintAPI())
 	boot.Main()
 }
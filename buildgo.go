@@ -0,0 +1,180 @@
+package build
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+)
+
+// defaultBuildTargets are the platforms built by build-go if BuildTargets is not provided.
+var defaultBuildTargets = []string{
+	"darwin/amd64",
+	"darwin/arm64",
+	"linux/amd64",
+	"linux/arm64",
+	"windows/amd64",
+}
+
+// BuildPackages returns an Option to set the main packages compiled by the build-go task,
+// for example "./cmd/server". The build-go task is only defined if packages are provided.
+func BuildPackages(packages ...string) Option {
+	return buildPackages{packages: packages}
+}
+
+type buildPackages struct {
+	packages []string
+}
+
+func (b buildPackages) apply(c *config) {
+	c.buildPackages = append(c.buildPackages, b.packages...)
+}
+
+// BuildTargets returns an Option to set the platforms compiled by the build-go task, in
+// GOOS/GOARCH format, for example "linux/amd64". If not provided, the default is Linux and
+// macOS on amd64 and arm64, and Windows on amd64.
+func BuildTargets(targets ...string) Option {
+	return buildTargets{targets: targets}
+}
+
+type buildTargets struct {
+	targets []string
+}
+
+func (b buildTargets) apply(c *config) {
+	c.buildTargets = append(c.buildTargets, b.targets...)
+}
+
+// BuildVersionVariable returns an Option to set the package variable that build-go stamps
+// with the GitVersion using -ldflags -X, for example "example.com/app/internal/version.Version".
+// If not provided, the default is "main.version". When provided, test-go also stamps the
+// variable so tests can use it, at the cost of invalidating the test cache for each version.
+func BuildVersionVariable(variable string) Option {
+	return buildVersionVariable(variable)
+}
+
+type buildVersionVariable string
+
+func (b buildVersionVariable) apply(c *config) {
+	c.buildVersionVariable = string(b)
+}
+
+// distPath returns the folder build-go writes binaries to.
+func distPath(conf config) string {
+	return filepath.Join(conf.artifactsPath, "dist")
+}
+
+// binaryName returns the name of the binary for a main package, matching go build.
+func binaryName(pkg string, goos string) string {
+	name := path.Base(pkg)
+	if name == "." || name == "/" {
+		if wd, err := os.Getwd(); err == nil {
+			name = filepath.Base(wd)
+		}
+	}
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+func buildGo(a *goyek.A, conf config) {
+	a.Helper()
+
+	targets := conf.buildTargets
+	if len(targets) == 0 {
+		targets = defaultBuildTargets
+	}
+
+	dist := distPath(conf)
+	if err := os.RemoveAll(dist); err != nil {
+		a.Errorf("failed to clean %s: %v", dist, err)
+		return
+	}
+
+	versionVariable := conf.buildVersionVariable
+	if versionVariable == "" {
+		versionVariable = "main.version"
+	}
+	ldflags := fmt.Sprintf("-s -w -buildid= -X %s=%s", versionVariable, buildVersion(a, "."))
+
+	var binaries []string
+	for _, target := range targets {
+		goos, goarch, ok := strings.Cut(target, "/")
+		if !ok {
+			a.Errorf("invalid build target %q, must be GOOS/GOARCH", target)
+			continue
+		}
+		for _, pkg := range conf.buildPackages {
+			out := filepath.Join(dist, goos+"_"+goarch, binaryName(pkg, goos))
+			if !cmd.Exec(a, fmt.Sprintf(`go build -trimpath -buildvcs=true -ldflags="%s" -o %s %s`, ldflags, out, pkg),
+				cmd.Env("CGO_ENABLED", "0"), cmd.Env("GOOS", goos), cmd.Env("GOARCH", goarch)) {
+				continue
+			}
+			binaries = append(binaries, out)
+		}
+	}
+	if a.Failed() {
+		return
+	}
+
+	if err := writeChecksums(filepath.Join(dist, "checksums.txt"), dist, binaries); err != nil {
+		a.Error(err)
+	}
+}
+
+// buildVersion returns the version to stamp into binaries, the GitVersion of the module in
+// dir or "dev" if it cannot be determined.
+func buildVersion(a *goyek.A, dir string) string {
+	a.Helper()
+	v, err := GitVersion(a.Context(), dir)
+	if err != nil {
+		a.Logf("failed to determine version, using dev: %v", err)
+		return "dev"
+	}
+	return v.String()
+}
+
+// writeChecksums writes the SHA256 of each file to path in the format of sha256sum, with
+// file names relative to dir.
+func writeChecksums(path string, dir string, files []string) error {
+	var sums bytes.Buffer
+	slices.Sort(files)
+	for _, f := range files {
+		sum, err := sha256File(f)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, f)
+		if err != nil {
+			return fmt.Errorf("build: computing path of %s: %w", f, err)
+		}
+		_, _ = fmt.Fprintf(&sums, "%s  %s\n", sum, filepath.ToSlash(rel))
+	}
+	if err := os.WriteFile(path, sums.Bytes(), 0o644); err != nil { //nolint:gosec // common for build artifacts
+		return fmt.Errorf("build: writing checksums: %w", err)
+	}
+	return nil
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("build: opening %s: %w", path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("build: reading %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,166 @@
+package build
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/goyek/goyek/v3"
+)
+
+func TestBinaryName(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "myapp")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	tests := []struct {
+		pkg  string
+		goos string
+		want string
+	}{
+		{pkg: "./cmd/server", goos: "linux", want: "server"},
+		{pkg: "./cmd/server", goos: "windows", want: "server.exe"},
+		{pkg: "example.com/tool/cmd/tool", goos: "darwin", want: "tool"},
+		{pkg: ".", goos: "linux", want: "myapp"},
+		{pkg: ".", goos: "windows", want: "myapp.exe"},
+	}
+	for _, tc := range tests {
+		if got := binaryName(tc.pkg, tc.goos); got != tc.want {
+			t.Errorf("binaryName(%q, %q) = %q, want %q", tc.pkg, tc.goos, got, tc.want)
+		}
+	}
+}
+
+func TestWriteChecksums(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "linux_amd64", "b"), filepath.Join(dir, "a")}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte("hello\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, "checksums.txt")
+	if err := writeChecksums(path, dir, files); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sha256 of "hello\n", as printed by sha256sum.
+	sum := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	want := sum + "  a\n" + sum + "  linux_amd64/b\n"
+	if string(got) != want {
+		t.Errorf("checksums =\n%s\nwant\n%s", got, want)
+	}
+
+	if err := writeChecksums(path, dir, []string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("writeChecksums of missing file succeeded, want error")
+	}
+}
+
+func TestBuildGo(t *testing.T) {
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOWORK", "off")
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":             "module example.com/app\n\ngo 1.25\n",
+		"cmd/server/main.go": "package main\n\nimport \"os\"\n\nvar version string\n\nfunc main() { _, _ = os.Stdout.WriteString(version) }\n",
+	})
+	t.Chdir(dir)
+
+	conf := config{
+		artifactsPath: "out",
+		buildPackages: []string{"./cmd/server"},
+		buildTargets:  []string{runtime.GOOS + "/" + runtime.GOARCH, "windows/arm64"},
+	}
+	// A stale file from a previous build is removed.
+	writeFiles(t, dir, map[string]string{"out/dist/stale": ""})
+
+	var out bytes.Buffer
+	res := goyek.NewRunner(func(a *goyek.A) {
+		buildGo(a, conf)
+	})(goyek.Input{Output: &out})
+	if res.Status != goyek.StatusPassed {
+		t.Fatalf("buildGo status = %v, output:\n%s", res.Status, out.String())
+	}
+
+	if fileExists(filepath.Join("out", "dist", "stale")) {
+		t.Error("stale file in dist not removed")
+	}
+	host := filepath.Join("out", "dist", runtime.GOOS+"_"+runtime.GOARCH, binaryName("./cmd/server", runtime.GOOS))
+	windows := filepath.Join("out", "dist", "windows_arm64", "server.exe")
+	for _, b := range []string{host, windows} {
+		info, err := buildinfo.ReadFile(b)
+		if err != nil {
+			t.Fatalf("reading build info of %s: %v", b, err)
+		}
+		settings := map[string]string{}
+		for _, s := range info.Settings {
+			settings[s.Key] = s.Value
+		}
+		if settings["CGO_ENABLED"] != "0" || settings["-trimpath"] != "true" {
+			t.Errorf("%s build settings = %v, want CGO disabled and trimpath", b, settings)
+		}
+	}
+
+	// Not in a git repository, so the version falls back to dev.
+	version, err := exec.Command(host).Output()
+	if err != nil {
+		t.Fatalf("running %s: %v", host, err)
+	}
+	if string(version) != "dev" {
+		t.Errorf("stamped version = %q, want dev", version)
+	}
+
+	sums, err := os.ReadFile(filepath.Join("out", "dist", "checksums.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(sums)), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[1], "  windows_arm64/server.exe") {
+		t.Errorf("checksums =\n%s", sums)
+	}
+}
+
+func TestBuildGoInvalidTarget(t *testing.T) {
+	t.Chdir(t.TempDir())
+	conf := config{
+		artifactsPath: "out",
+		buildPackages: []string{"./cmd/server"},
+		buildTargets:  []string{"linux"},
+	}
+	var out bytes.Buffer
+	res := goyek.NewRunner(func(a *goyek.A) {
+		buildGo(a, conf)
+	})(goyek.Input{Output: &out})
+	if res.Status != goyek.StatusFailed || !strings.Contains(out.String(), `invalid build target "linux"`) {
+		t.Errorf("status = %v, output:\n%s", res.Status, out.String())
+	}
+}
+
+// writeFiles writes the files with their content under dir, creating parent folders.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
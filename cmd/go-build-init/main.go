@@ -0,0 +1,176 @@
+// Command go-build-init bootstraps a repository to use go-build, creating the build folder
+// and recommended configuration files. Existing files are never overwritten unless -force
+// is passed.
+//
+//	go run github.com/curioswitch/go-build/cmd/go-build-init@latest
+package main
+
+import (
+	"bytes"
+	"embed"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+
+	"golang.org/x/mod/modfile"
+)
+
+//go:embed templates
+var templates embed.FS
+
+type templateData struct {
+	// Folder is the name of the build folder.
+	Folder string
+	// Module is the module path of the repository's root module, if any.
+	Module string
+}
+
+type file struct {
+	template string
+	path     string
+}
+
+func main() {
+	dir := flag.String("dir", ".", "the root directory of the repository to initialize")
+	folder := flag.String("folder", "build", "the name of the build folder to create")
+	force := flag.Bool("force", false, "overwrite existing files")
+	noCI := flag.Bool("no-ci", false, "do not create a GitHub Actions workflow")
+	flag.Parse()
+
+	if err := run(*dir, *folder, *force, *noCI); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, folder string, force bool, noCI bool) error {
+	data := templateData{
+		Folder: folder,
+		Module: rootModule(dir),
+	}
+
+	files := []file{
+		{template: "main.go.tmpl", path: filepath.Join(folder, "main.go")},
+		{template: "golangci.yml.tmpl", path: ".golangci.yml"},
+		{template: "rumdl.toml.tmpl", path: ".rumdl.toml"},
+		{template: "ryl.toml.tmpl", path: ".ryl.toml"},
+		{template: "renovate.json.tmpl", path: "renovate.json"},
+	}
+	if !noCI {
+		files = append(files, file{template: "ci.yaml.tmpl", path: filepath.Join(".github", "workflows", "ci.yaml")})
+	}
+
+	for _, f := range files {
+		if err := writeTemplate(dir, f, data, force); err != nil {
+			return err
+		}
+	}
+
+	buildDir := filepath.Join(dir, folder)
+	if fileExists(filepath.Join(buildDir, "go.mod")) {
+		fmt.Printf("skipped %s, already exists\n", filepath.Join(folder, "go.mod"))
+	} else {
+		if err := initBuildModule(buildDir); err != nil {
+			return err
+		}
+		fmt.Printf("created %s\n", filepath.Join(folder, "go.mod"))
+	}
+
+	if fileExists(filepath.Join(dir, "go.work")) {
+		if err := goCmd(dir, false, "work", "use", "./"+filepath.ToSlash(folder)); err != nil {
+			return err
+		}
+		fmt.Println("added", folder, "to go.work")
+	} else {
+		args := []string{"work", "init"}
+		if fileExists(filepath.Join(dir, "go.mod")) {
+			args = append(args, ".")
+		}
+		args = append(args, "./"+filepath.ToSlash(folder))
+		if err := goCmd(dir, false, args...); err != nil {
+			return err
+		}
+		fmt.Println("created go.work")
+	}
+
+	fmt.Printf("done, run `go run ./%s -h` to see available tasks\n", filepath.ToSlash(folder))
+	return nil
+}
+
+// initBuildModule creates the go.mod for the build folder, removing it if dependencies could
+// not be added so that running again retries.
+func initBuildModule(buildDir string) error {
+	// The build folder is not yet part of any workspace so it must be initialized standalone.
+	if err := goCmd(buildDir, true, "mod", "init", "build"); err != nil {
+		return err
+	}
+	if err := goCmd(buildDir, true, "get", "github.com/curioswitch/go-build@latest", "github.com/goyek/x@latest"); err != nil {
+		_ = os.Remove(filepath.Join(buildDir, "go.mod"))
+		return err
+	}
+	if err := goCmd(buildDir, true, "mod", "tidy"); err != nil {
+		_ = os.Remove(filepath.Join(buildDir, "go.mod"))
+		_ = os.Remove(filepath.Join(buildDir, "go.sum"))
+		return err
+	}
+	return nil
+}
+
+func writeTemplate(dir string, f file, data templateData, force bool) error {
+	path := filepath.Join(dir, f.path)
+	if !force && fileExists(path) {
+		fmt.Printf("skipped %s, already exists (use -force to overwrite)\n", f.path)
+		return nil
+	}
+
+	tmpl, err := template.ParseFS(templates, "templates/"+f.template)
+	if err != nil {
+		return fmt.Errorf("parsing template %s: %w", f.template, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("executing template %s: %w", f.template, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec // common for source folders
+		return fmt.Errorf("creating directory for %s: %w", f.path, err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil { //nolint:gosec // common for source files
+		return fmt.Errorf("writing %s: %w", f.path, err)
+	}
+	fmt.Printf("created %s\n", f.path)
+	return nil
+}
+
+func goCmd(dir string, noWork bool, args ...string) error {
+	c := exec.Command("go", args...)
+	c.Dir = dir
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = os.Environ()
+	if noWork {
+		c.Env = append(c.Env, "GOWORK=off")
+	}
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("running go %v: %w", args, err)
+	}
+	return nil
+}
+
+func rootModule(dir string) string {
+	b, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	return modfile.ModulePath(b)
+}
+
+func fileExists(p string) bool {
+	if _, err := os.Stat(p); err == nil {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// dockerInstruction is a single instruction of a Dockerfile, with continuation lines and
+// heredocs joined.
+type dockerInstruction struct {
+	// Line is the line number the instruction starts on.
+	Line int
+	// Cmd is the instruction in upper case, for example "RUN".
+	Cmd string
+	// Flags are the leading flags of the instruction, for example "--platform=linux/amd64".
+	Flags []string
+	// Args is the rest of the instruction.
+	Args string
+	// Ignore are the rules ignored for the instruction with a preceding
+	// `# hadolint ignore=DL3006,DL3007` comment.
+	Ignore []string
+}
+
+var (
+	dockerDirective = regexp.MustCompile(`^#\s*([a-zA-Z]+)\s*=\s*(.+?)\s*$`)
+	dockerIgnore    = regexp.MustCompile(`^#\s*hadolint\s+ignore=([A-Z0-9,\s]+)$`)
+	dockerHeredoc   = regexp.MustCompile(`<<-?\s*["']?([A-Za-z_][A-Za-z0-9_]*)["']?`)
+)
+
+// parseDockerfile parses the instructions of a Dockerfile. It supports the escape parser
+// directive, line continuations, comments and heredocs, which is enough for linting though
+// not for building.
+func parseDockerfile(r io.Reader) ([]dockerInstruction, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1024*1024)
+
+	var res []dockerInstruction
+	escape := `\`
+	directives := true
+	var ignore []string
+	line := 0
+	for s.Scan() {
+		line++
+		text := strings.TrimSpace(s.Text())
+		if directives {
+			if m := dockerDirective.FindStringSubmatch(text); m != nil {
+				if strings.EqualFold(m[1], "escape") {
+					escape = m[2]
+				}
+				continue
+			}
+			directives = false
+		}
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "#") {
+			if m := dockerIgnore.FindStringSubmatch(text); m != nil {
+				for _, rule := range strings.Split(m[1], ",") {
+					ignore = append(ignore, strings.TrimSpace(rule))
+				}
+			}
+			continue
+		}
+
+		start := line
+		for strings.HasSuffix(text, escape) && s.Scan() {
+			line++
+			next := strings.TrimSpace(s.Text())
+			if next == "" || strings.HasPrefix(next, "#") {
+				// Comments and empty lines within continuations are removed.
+				continue
+			}
+			text = strings.TrimSuffix(text, escape) + " " + next
+		}
+		text = strings.TrimSuffix(text, escape)
+
+		for _, m := range dockerHeredoc.FindAllStringSubmatch(text, -1) {
+			var body []string
+			for s.Scan() {
+				line++
+				if strings.TrimSpace(s.Text()) == m[1] {
+					break
+				}
+				body = append(body, s.Text())
+			}
+			text += "\n" + strings.Join(body, "\n")
+		}
+
+		cmd, args, _ := strings.Cut(text, " ")
+		inst := dockerInstruction{Line: start, Cmd: strings.ToUpper(cmd), Ignore: ignore}
+		args = strings.TrimSpace(args)
+		for strings.HasPrefix(args, "--") {
+			flag, rest, _ := strings.Cut(args, " ")
+			inst.Flags = append(inst.Flags, flag)
+			args = strings.TrimSpace(rest)
+		}
+		inst.Args = args
+		res = append(res, inst)
+		ignore = nil
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("build: reading Dockerfile: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,195 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+)
+
+var (
+	dockerShellSeparator = regexp.MustCompile(`&&|\|\||[;|\n]`)
+	dockerArchive        = regexp.MustCompile(`\.(tar|tar\.gz|tgz|tar\.bz2|tbz2|tar\.xz|txz|tar\.zst)$`)
+	dockerWindowsPath    = regexp.MustCompile(`^[a-zA-Z]:`)
+	dockerAptYes         = regexp.MustCompile(`^(-[a-z]*y[a-z]*|-qq|--yes|--assume-yes)$`)
+)
+
+// isDockerfile returns whether name is a Dockerfile, including variants such as
+// Dockerfile.dev which format-shell does not format.
+func isDockerfile(name string) bool {
+	return name == "Dockerfile" || strings.HasPrefix(name, "Dockerfile.") || strings.HasSuffix(name, ".dockerfile")
+}
+
+func lintDocker(a *goyek.A, conf config, runReviewdog string) {
+	a.Helper()
+	files, err := findFiles(conf, func(path string) bool { return isDockerfile(filepath.Base(path)) })
+	if err != nil {
+		a.Error(err)
+		return
+	}
+	if len(files) == 0 {
+		a.Skip("no Dockerfiles")
+	}
+
+	var report bytes.Buffer
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			a.Errorf("failed to open %s: %v", path, err)
+			continue
+		}
+		insts, err := parseDockerfile(f)
+		_ = f.Close()
+		if err != nil {
+			a.Errorf("failed to parse %s: %v", path, err)
+			continue
+		}
+		for _, d := range lintDockerfile(insts) {
+			_, _ = fmt.Fprintf(&report, "%s:%d:1: %s %s\n", filepath.ToSlash(path), d.Line, d.Code, d.Message)
+		}
+	}
+	if report.Len() > 0 {
+		reportReviewdog(conf, a, runReviewdog, `-efm="%f:%l:%c: %m" -name=lint-docker`, report.String())
+	}
+}
+
+// lintDockerfile checks the instructions of a Dockerfile against rules similar to hadolint.
+// Codes match hadolint where it has an equivalent rule so its ignore comments can be reused.
+func lintDockerfile(insts []dockerInstruction) []lintDiagnostic {
+	var res []lintDiagnostic
+	report := func(inst dockerInstruction, code string, format string, args ...any) {
+		if slices.Contains(inst.Ignore, code) {
+			return
+		}
+		res = append(res, lintDiagnostic{Line: inst.Line, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	stages := map[string]bool{}
+	var cmd, entrypoint, user *dockerInstruction
+	for i, inst := range insts {
+		fields := strings.Fields(inst.Args)
+		switch inst.Cmd {
+		case "FROM":
+			cmd, entrypoint, user = nil, nil, nil
+			if len(fields) == 0 {
+				continue
+			}
+			if len(fields) >= 3 && strings.EqualFold(fields[1], "as") {
+				stages[strings.ToLower(fields[2])] = true
+			}
+			image := fields[0]
+			if image == "scratch" || stages[strings.ToLower(image)] || strings.Contains(image, "$") {
+				continue
+			}
+			name := image
+			if i := strings.LastIndex(name, "/"); i >= 0 {
+				name = name[i+1:]
+			}
+			_, tag, hasTag := strings.Cut(strings.Split(name, "@")[0], ":")
+			switch {
+			case !hasTag && !strings.Contains(image, "@"):
+				report(inst, "DL3006", "pin base image %s to an explicit tag, and ideally a digest", image)
+			case tag == "latest":
+				report(inst, "DL3007", "base image %s uses the latest tag, pin it to a version", image)
+			}
+		case "MAINTAINER":
+			report(inst, "DL4000", "MAINTAINER is deprecated, use LABEL org.opencontainers.image.authors instead")
+		case "WORKDIR":
+			if len(fields) > 0 && !strings.HasPrefix(fields[0], "/") && !strings.HasPrefix(fields[0], "$") &&
+				!dockerWindowsPath.MatchString(fields[0]) {
+				report(inst, "DL3000", "use an absolute WORKDIR")
+			}
+		case "USER":
+			user = &insts[i]
+		case "CMD", "ENTRYPOINT":
+			if inst.Cmd == "CMD" {
+				if cmd != nil {
+					report(inst, "DL4003", "multiple CMD instructions in a stage, only the last one takes effect")
+				}
+				cmd = &insts[i]
+			} else {
+				if entrypoint != nil {
+					report(inst, "DL4004", "multiple ENTRYPOINT instructions in a stage, only the last one takes effect")
+				}
+				entrypoint = &insts[i]
+			}
+			if !strings.HasPrefix(inst.Args, "[") {
+				report(inst, "DL3025", "use the JSON form of %s so it receives signals", inst.Cmd)
+			}
+		case "ADD":
+			if slices.ContainsFunc(inst.Flags, func(f string) bool { return strings.HasPrefix(f, "--checksum") }) || len(fields) < 2 {
+				continue
+			}
+			for _, src := range fields[:len(fields)-1] {
+				switch {
+				case strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://"):
+					report(inst, "DL3020", "ADD of URL %s is not verified, add --checksum or download it in RUN", src)
+				case strings.HasPrefix(src, "git@") || dockerArchive.MatchString(src) || strings.HasPrefix(src, "<<"):
+				default:
+					report(inst, "DL3020", "use COPY instead of ADD for file %s", src)
+				}
+			}
+		case "RUN":
+			lintDockerRun(inst, report)
+		}
+	}
+	// Only the final stage ends up in the image.
+	if user != nil && isRootUser(user.Args) {
+		report(*user, "DL3002", "last USER of the final stage should not be root")
+	}
+	return res
+}
+
+func lintDockerRun(inst dockerInstruction, report func(inst dockerInstruction, code string, format string, args ...any)) {
+	script := inst.Args
+	if strings.HasPrefix(script, "[") {
+		// Exec form, approximate the command it runs.
+		script = strings.NewReplacer(`"`, "", "[", "", "]", "", ",", " ").Replace(script)
+	}
+	aptInstall := false
+	for _, c := range dockerShellSeparator.Split(script, -1) {
+		fields := strings.Fields(c)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "sudo" {
+			report(inst, "DL3004", "do not use sudo, it has unpredictable behavior in containers, use USER instead")
+			fields = fields[1:]
+			if len(fields) == 0 {
+				continue
+			}
+		}
+		has := func(flags ...string) bool {
+			return slices.ContainsFunc(fields, func(f string) bool { return slices.Contains(flags, f) })
+		}
+		switch {
+		case fields[0] == "cd":
+			report(inst, "DL3003", "use WORKDIR to switch directories")
+		case (fields[0] == "apt-get" || fields[0] == "apt") && has("install"):
+			aptInstall = true
+			if !slices.ContainsFunc(fields, dockerAptYes.MatchString) {
+				report(inst, "DL3014", "use the -y flag with apt-get install to avoid prompts")
+			}
+			if !has("--no-install-recommends") {
+				report(inst, "DL3015", "use --no-install-recommends with apt-get install to avoid unneeded packages")
+			}
+		case fields[0] == "apk" && has("add") && !has("--no-cache"):
+			report(inst, "DL3019", "use --no-cache with apk add to avoid keeping the package index in the image")
+		case (fields[0] == "pip" || fields[0] == "pip3") && has("install") && !has("--no-cache-dir"):
+			report(inst, "DL3042", "use --no-cache-dir with pip install to avoid keeping the cache in the image")
+		}
+	}
+	if aptInstall && !strings.Contains(script, "/var/lib/apt/lists") {
+		report(inst, "DL3009", "delete the apt-get lists with `rm -rf /var/lib/apt/lists/*` in the same RUN after installing")
+	}
+}
+
+func isRootUser(user string) bool {
+	name, _, _ := strings.Cut(strings.TrimSpace(user), ":")
+	return name == "root" || name == "0"
+}
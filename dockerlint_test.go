@@ -0,0 +1,153 @@
+package build
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseDockerfile(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []dockerInstruction
+	}{
+		{
+			name: "instructions and comments",
+			src:  "# syntax=docker/dockerfile:1\n\nFROM --platform=linux/amd64 golang:1.25 AS build\n# comment\nrun go build\n",
+			want: []dockerInstruction{
+				{Line: 3, Cmd: "FROM", Flags: []string{"--platform=linux/amd64"}, Args: "golang:1.25 AS build"},
+				{Line: 5, Cmd: "RUN", Args: "go build"},
+			},
+		},
+		{
+			name: "continuation",
+			src:  "FROM alpine:3\nRUN apk add \\\n    # comment in continuation\n\n    curl \\\n    git\nUSER app\n",
+			want: []dockerInstruction{
+				{Line: 1, Cmd: "FROM", Args: "alpine:3"},
+				{Line: 2, Cmd: "RUN", Args: "apk add  curl  git"},
+				{Line: 7, Cmd: "USER", Args: "app"},
+			},
+		},
+		{
+			name: "escape directive",
+			src:  "# escape=`\nFROM mcr.microsoft.com/windows/servercore:ltsc2022\nWORKDIR C:\\app\nRUN dir `\n    C:\\\n",
+			want: []dockerInstruction{
+				{Line: 2, Cmd: "FROM", Args: "mcr.microsoft.com/windows/servercore:ltsc2022"},
+				{Line: 3, Cmd: "WORKDIR", Args: `C:\app`},
+				{Line: 4, Cmd: "RUN", Args: `dir  C:\`},
+			},
+		},
+		{
+			name: "directive after instruction is a comment",
+			src:  "FROM alpine:3\n# escape=`\nRUN echo \\\n  hi\n",
+			want: []dockerInstruction{
+				{Line: 1, Cmd: "FROM", Args: "alpine:3"},
+				{Line: 3, Cmd: "RUN", Args: "echo  hi"},
+			},
+		},
+		{
+			name: "heredoc",
+			src:  "FROM alpine:3\nRUN <<EOF\ncd /tmp\nFROM inside heredoc\nEOF\nCOPY <<-'CONF' /etc/app.conf\nkey=value\nCONF\nUSER app\n",
+			want: []dockerInstruction{
+				{Line: 1, Cmd: "FROM", Args: "alpine:3"},
+				{Line: 2, Cmd: "RUN", Args: "<<EOF\ncd /tmp\nFROM inside heredoc"},
+				{Line: 6, Cmd: "COPY", Args: "<<-'CONF' /etc/app.conf\nkey=value"},
+				{Line: 9, Cmd: "USER", Args: "app"},
+			},
+		},
+		{
+			name: "hadolint ignore",
+			src:  "# hadolint ignore=DL3006, DL3007\nFROM alpine\n# hadolint ignore=DL3003\n\nRUN cd /tmp\nRUN cd /\n",
+			want: []dockerInstruction{
+				{Line: 2, Cmd: "FROM", Args: "alpine", Ignore: []string{"DL3006", "DL3007"}},
+				{Line: 5, Cmd: "RUN", Args: "cd /tmp", Ignore: []string{"DL3003"}},
+				{Line: 6, Cmd: "RUN", Args: "cd /"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseDockerfile(strings.NewReader(tc.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseDockerfile() =\n%+v\nwant\n%+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLintDockerfile(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		// want is the line and code of each diagnostic, for example "2:DL3006".
+		want []string
+	}{
+		{name: "clean", src: "FROM golang:1.25 AS build\nWORKDIR /src\nRUN go build -o /app .\nFROM gcr.io/distroless/static@sha256:abc\nCOPY --from=build /app /app\nUSER nonroot\nENTRYPOINT [\"/app\"]\n"},
+		{name: "DL3006 untagged", src: "FROM alpine\n", want: []string{"1:DL3006"}},
+		{name: "DL3006 registry port", src: "FROM localhost:5000/alpine\n", want: []string{"1:DL3006"}},
+		{name: "DL3006 skips scratch, stages and args", src: "FROM alpine:3 AS base\nFROM base\nFROM scratch\nFROM ${IMAGE}\n"},
+		{name: "DL3007", src: "FROM alpine:latest\n", want: []string{"1:DL3007"}},
+		{name: "DL3000", src: "FROM alpine:3\nWORKDIR app\nWORKDIR /app\nWORKDIR $HOME\n", want: []string{"2:DL3000"}},
+		{name: "DL3002", src: "FROM alpine:3\nUSER root\n", want: []string{"2:DL3002"}},
+		{name: "DL3002 uid", src: "FROM alpine:3\nUSER 0:0\n", want: []string{"2:DL3002"}},
+		{name: "DL3002 reset by FROM", src: "FROM alpine:3 AS build\nUSER root\nFROM alpine:3\nUSER app\n"},
+		{name: "DL3002 only final stage", src: "FROM alpine:3 AS build\nUSER root\nFROM alpine:3\n"},
+		{name: "DL3003", src: "FROM alpine:3\nRUN cd /tmp && make\n", want: []string{"2:DL3003"}},
+		{name: "DL3004", src: "FROM alpine:3\nRUN sudo make install\n", want: []string{"2:DL3004"}},
+		{
+			name: "DL3009 DL3014 DL3015",
+			src:  "FROM debian:12\nRUN apt-get update && apt-get install curl\n",
+			want: []string{"2:DL3014", "2:DL3015", "2:DL3009"},
+		},
+		{name: "apt-get clean", src: "FROM debian:12\nRUN apt-get update && apt-get install -y --no-install-recommends curl && rm -rf /var/lib/apt/lists/*\n"},
+		{name: "DL3019", src: "FROM alpine:3\nRUN apk add curl\nRUN apk add --no-cache git\n", want: []string{"2:DL3019"}},
+		{name: "DL3020 file", src: "FROM alpine:3\nADD app.conf /etc/\n", want: []string{"2:DL3020"}},
+		{name: "DL3020 URL", src: "FROM alpine:3\nADD https://example.com/a /a\n", want: []string{"2:DL3020"}},
+		{name: "DL3020 allowed", src: "FROM alpine:3\nADD rootfs.tar.gz /\nADD --checksum=sha256:abc https://example.com/a /a\nADD git@github.com:a/b.git /b\n"},
+		{name: "DL3025", src: "FROM alpine:3\nCMD echo hi\nENTRYPOINT /app\n", want: []string{"2:DL3025", "3:DL3025"}},
+		{name: "DL3042", src: "FROM python:3\nRUN pip install flask\nRUN pip3 install --no-cache-dir flask\n", want: []string{"2:DL3042"}},
+		{name: "DL4000", src: "FROM alpine:3\nMAINTAINER me\n", want: []string{"2:DL4000"}},
+		{name: "DL4003", src: "FROM alpine:3\nCMD [\"a\"]\nCMD [\"b\"]\nFROM alpine:3\nCMD [\"c\"]\n", want: []string{"3:DL4003"}},
+		{name: "DL4004", src: "FROM alpine:3\nENTRYPOINT [\"a\"]\nENTRYPOINT [\"b\"]\n", want: []string{"3:DL4004"}},
+		{name: "exec form RUN", src: "FROM alpine:3\nRUN [\"sudo\", \"make\"]\n", want: []string{"2:DL3004"}},
+		{name: "heredoc RUN", src: "FROM alpine:3\nRUN <<EOF\ncd /tmp\nEOF\n", want: []string{"2:DL3003"}},
+		{name: "ignored", src: "# hadolint ignore=DL3006\nFROM alpine\n# hadolint ignore=DL3014,DL3015\nRUN apt-get install curl\n", want: []string{"4:DL3009"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			insts, err := parseDockerfile(strings.NewReader(tc.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, d := range lintDockerfile(insts) {
+				got = append(got, fmt.Sprintf("%d:%s", d.Line, d.Code))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("diagnostics = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsDockerfile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Dockerfile":        true,
+		"Dockerfile.dev":    true,
+		"app.dockerfile":    true,
+		"Dockerfile-old":    false,
+		"dockerfile.go":     false,
+		".dockerignore":     false,
+		"Containerfile.txt": false,
+	} {
+		if got := isDockerfile(name); got != want {
+			t.Errorf("isDockerfile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
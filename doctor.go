@@ -0,0 +1,185 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"go/version"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"golang.org/x/mod/modfile"
+)
+
+// doctor reports the result of environment checks with actionable fixes.
+type doctor struct {
+	a        *goyek.A
+	problems int
+}
+
+func (d *doctor) ok(format string, args ...any) {
+	_, _ = fmt.Fprintf(d.a.Output(), "[ok]   %s\n", fmt.Sprintf(format, args...))
+}
+
+func (d *doctor) warn(msg string, fix string) {
+	_, _ = fmt.Fprintf(d.a.Output(), "[warn] %s\n       fix: %s\n", msg, fix)
+}
+
+func (d *doctor) fail(msg string, fix string) {
+	d.problems++
+	_, _ = fmt.Fprintf(d.a.Output(), "[fail] %s\n       fix: %s\n", msg, fix)
+}
+
+func (d *doctor) info(format string, args ...any) {
+	_, _ = fmt.Fprintf(d.a.Output(), "[info] %s\n", fmt.Sprintf(format, args...))
+}
+
+func runDoctor(a *goyek.A, conf config) {
+	d := &doctor{a: a}
+
+	root, target := pathRelativeToRoot()
+	if root == "" {
+		d.warn("could not find repository root containing .git or go.work",
+			"run the build from inside a git repository or Go workspace")
+	} else {
+		d.ok("repository root is %s, running in %q", root, target)
+	}
+
+	d.checkGit()
+	d.checkGoVersion(root)
+	d.checkCI(conf)
+	d.checkTools(conf)
+	d.reportTasks()
+
+	if d.problems > 0 {
+		a.Errorf("found %d problems", d.problems)
+	}
+}
+
+func (d *doctor) checkGit() {
+	if _, err := exec.LookPath("git"); err != nil {
+		d.fail("git is not installed or not in PATH", "install git from https://git-scm.com/downloads")
+		return
+	}
+	d.ok("git is installed")
+}
+
+func (d *doctor) checkGoVersion(root string) {
+	var out bytes.Buffer
+	c := exec.CommandContext(d.a.Context(), "go", "env", "GOVERSION")
+	c.Stdout = &out
+	if err := c.Run(); err != nil {
+		d.fail(fmt.Sprintf("could not determine Go version: %v", err), "install Go from https://go.dev/dl/")
+		return
+	}
+	goVersion := strings.TrimSpace(out.String())
+
+	var path string
+	var goDirective, toolchain string
+	switch {
+	case root != "" && fileExists(filepath.Join(root, "go.work")):
+		path = filepath.Join(root, "go.work")
+		if b, err := os.ReadFile(path); err == nil {
+			if f, err := modfile.ParseWork(path, b, nil); err == nil {
+				goDirective, toolchain = goDirectives(f.Go, f.Toolchain)
+			}
+		}
+	case fileExists("go.mod"):
+		path = "go.mod"
+		if b, err := os.ReadFile(path); err == nil {
+			if f, err := modfile.ParseLax(path, b, nil); err == nil {
+				goDirective, toolchain = goDirectives(f.Go, f.Toolchain)
+			}
+		}
+	default:
+		d.ok("Go version is %s", goVersion)
+		return
+	}
+
+	required := toolchain
+	if required == "" && goDirective != "" {
+		required = "go" + goDirective
+	}
+	if required == "" {
+		d.ok("Go version is %s", goVersion)
+		return
+	}
+	if version.Compare(goVersion, required) < 0 {
+		d.fail(fmt.Sprintf("Go version %s is older than %s required by %s", goVersion, required, path),
+			fmt.Sprintf("install %s from https://go.dev/dl/ or unset GOTOOLCHAIN=local to allow automatic download", required))
+		return
+	}
+	d.ok("Go version %s satisfies %s required by %s", goVersion, required, path)
+}
+
+func goDirectives(g *modfile.Go, t *modfile.Toolchain) (string, string) {
+	var goDirective, toolchain string
+	if g != nil {
+		goDirective = g.Version
+	}
+	if t != nil {
+		toolchain = t.Name
+	}
+	return goDirective, toolchain
+}
+
+func (d *doctor) checkCI(conf config) {
+	if os.Getenv("GITHUB_ACTIONS") == "true" && os.Getenv("CI") != "true" {
+		d.warn("running in GitHub Actions but CI is not set to true, reviewdog reporting is disabled",
+			"set the environment variable CI=true in the workflow")
+	}
+	if os.Getenv("CI") != "true" {
+		d.info("CI is not set to true, lint results are printed instead of reported with reviewdog")
+		return
+	}
+	d.ok("CI is set to true")
+	if conf.disableReviewdog || conf.excluded("lint-go") {
+		return
+	}
+	if os.Getenv("REVIEWDOG_GITHUB_API_TOKEN") == "" {
+		d.fail("REVIEWDOG_GITHUB_API_TOKEN is not set, lint results cannot be reported as GitHub checks",
+			"set REVIEWDOG_GITHUB_API_TOKEN: ${{ secrets.GITHUB_TOKEN }} in the workflow env or use the DisableReviewdog option")
+		return
+	}
+	d.ok("REVIEWDOG_GITHUB_API_TOKEN is set")
+}
+
+func (d *doctor) checkTools(conf config) {
+	names := slices.Collect(maps.Keys(toolDownloads))
+	if !conf.excluded("test-go") && !slices.Contains(names, ToolGoTestsum) {
+		names = append(names, ToolGoTestsum)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		t := tools[name]
+		v := toolVersion(t)
+		var stderr bytes.Buffer
+		c := exec.CommandContext(d.a.Context(), "go", "list", "-m", t.Module+"@"+v) //nolint:gosec // tool from registry
+		c.Env = append(os.Environ(), "GOWORK=off")
+		c.Stderr = &stderr
+		if err := c.Run(); err != nil {
+			d.fail(fmt.Sprintf("tool %s %s could not be resolved: %s", name, v, strings.TrimSpace(stderr.String())),
+				fmt.Sprintf("check GOPROXY and network access, or set a valid version with ToolVersion(%q, version)", name))
+			continue
+		}
+		d.ok("tool %s %s can be resolved", name, v)
+	}
+}
+
+func (d *doctor) reportTasks() {
+	for _, t := range taskDecisions {
+		switch {
+		case t.status == taskExcluded:
+			d.info("task %s is excluded by the %s", t.task, t.reason)
+		case t.status == taskDisabled:
+			d.info("task %s is disabled: %s", t.task, t.reason)
+		case t.reason != "":
+			d.info("task %s: %s", t.task, t.reason)
+		}
+	}
+}
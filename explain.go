@@ -0,0 +1,69 @@
+package build
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/goyek/goyek/v3"
+)
+
+const (
+	taskEnabled  = "enabled"
+	taskExcluded = "excluded"
+	taskDisabled = "disabled"
+)
+
+// taskDecision records whether a default task was defined by DefineTasks and why.
+type taskDecision struct {
+	task   string
+	status string
+	reason string
+}
+
+// taskDecisions is the record of decisions made by DefineTasks, in definition order.
+var taskDecisions []taskDecision
+
+// shouldDefine returns whether the task should be defined, recording the decision.
+func (c *config) shouldDefine(task string) bool {
+	return c.shouldDefineDetected(task, true, "")
+}
+
+// shouldDefineDetected returns whether the task should be defined given the result of
+// auto-detection, recording the decision and the reason if detection disabled it.
+func (c *config) shouldDefineDetected(task string, detected bool, reason string) bool {
+	switch {
+	case c.excluded(task):
+		taskDecisions = append(taskDecisions, taskDecision{task: task, status: taskExcluded, reason: "ExcludeTasks option"})
+		return false
+	case !detected:
+		taskDecisions = append(taskDecisions, taskDecision{task: task, status: taskDisabled, reason: reason})
+		return false
+	default:
+		taskDecisions = append(taskDecisions, taskDecision{task: task, status: taskEnabled})
+		return true
+	}
+}
+
+// explainDetection records a decision from auto-detection that changes the behavior of
+// an enabled task without disabling it.
+func explainDetection(task string, reason string) {
+	for i := range taskDecisions {
+		if d := &taskDecisions[i]; d.task == task {
+			if d.reason != "" {
+				d.reason += "; "
+			}
+			d.reason += reason
+			return
+		}
+	}
+	taskDecisions = append(taskDecisions, taskDecision{task: task, status: taskEnabled, reason: reason})
+}
+
+func printTaskDecisions(a *goyek.A) {
+	w := tabwriter.NewWriter(a.Output(), 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TASK\tSTATUS\tREASON")
+	for _, d := range taskDecisions {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", d.task, d.status, d.reason)
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,36 @@
+package build
+
+import "testing"
+
+func TestExplainGoDetection(t *testing.T) {
+	tests := []struct {
+		name          string
+		hasGoMod      bool
+		hasBuildGoMod bool
+		want          string
+	}{
+		{name: "both", hasGoMod: true, hasBuildGoMod: true, want: ""},
+		{name: "root only", hasGoMod: true, want: "no go.mod in build folder build, checked as part of the root module"},
+		{name: "build only", hasBuildGoMod: true, want: "no go.mod, only build folder build checked and go mod tidy skipped"},
+		{name: "neither", want: "no go.mod in root or build folder build, no Go code checked"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			old := taskDecisions
+			t.Cleanup(func() { taskDecisions = old })
+			taskDecisions = nil
+
+			conf := config{buildFolder: "build"}
+			if !conf.shouldDefine("lint-go") {
+				t.Fatal("lint-go not defined")
+			}
+			explainGoDetection("lint-go", conf, tc.hasGoMod, tc.hasBuildGoMod)
+			if len(taskDecisions) != 1 {
+				t.Fatalf("decisions = %v, want one", taskDecisions)
+			}
+			if got := taskDecisions[0].reason; got != tc.want {
+				t.Errorf("reason = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ go 1.25.0
 require (
 	github.com/goyek/goyek/v3 v3.0.1
 	github.com/goyek/x v0.4.0
+	golang.org/x/mod v0.40.0
 )
 
 require (
@@ -0,0 +1,179 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"golang.org/x/mod/modfile"
+)
+
+// GoModToolchain returns an Option to enforce a policy for the toolchain directive of go.mod
+// files checked by lint-go. "none" requires there to be no toolchain directive, and any other
+// value requires the directive to be that toolchain, for example "go1.25.1". If not provided,
+// the toolchain directive is not checked.
+func GoModToolchain(toolchain string) Option {
+	return goModToolchain(toolchain)
+}
+
+type goModToolchain string
+
+func (g goModToolchain) apply(c *config) {
+	c.goModToolchain = string(g)
+}
+
+// BannedModules returns an Option to fail lint-go if a go.mod requires any of the modules,
+// directly or indirectly. Module paths may be globs, for example "github.com/pkg/errors" or
+// "github.com/golang/*".
+func BannedModules(modules ...string) Option {
+	return bannedModules{modules: modules}
+}
+
+type bannedModules struct {
+	modules []string
+}
+
+func (b bannedModules) apply(c *config) {
+	c.bannedModules = append(c.bannedModules, b.modules...)
+}
+
+// listedModule is a module in the build list as reported by `go list -m -json`.
+type listedModule struct {
+	Path       string
+	Version    string
+	Retracted  []string
+	Deprecated string
+	Error      *struct {
+		Err string
+	}
+}
+
+// lintGoMod checks the go.mod in dir for problems not covered by go mod tidy, reporting
+// each with its line number.
+func lintGoMod(a *goyek.A, conf config, dir string) {
+	a.Helper()
+	goMod := filepath.Join(dir, "go.mod")
+	b, err := os.ReadFile(goMod)
+	if err != nil {
+		a.Errorf("failed to read %s: %v", goMod, err)
+		return
+	}
+	f, err := modfile.Parse(goMod, b, nil)
+	if err != nil {
+		a.Errorf("failed to parse %s: %v", goMod, err)
+		return
+	}
+
+	switch {
+	case conf.goModToolchain == "":
+	case conf.goModToolchain == "none":
+		if f.Toolchain != nil {
+			a.Errorf("%s:%d: toolchain directive is not allowed, remove it with `go mod edit -toolchain=none`",
+				goMod, f.Toolchain.Syntax.Start.Line)
+		}
+	case f.Toolchain == nil:
+		a.Errorf("%s: toolchain directive %s is required, add it with `go mod edit -toolchain=%s`",
+			goMod, conf.goModToolchain, conf.goModToolchain)
+	case f.Toolchain.Name != conf.goModToolchain:
+		a.Errorf("%s:%d: toolchain directive %s must be %s", goMod, f.Toolchain.Syntax.Start.Line,
+			f.Toolchain.Name, conf.goModToolchain)
+	}
+
+	for _, r := range f.Require {
+		for _, banned := range conf.bannedModules {
+			if ok, _ := path.Match(banned, r.Mod.Path); ok {
+				a.Errorf("%s:%d: module %s is banned", goMod, r.Syntax.Start.Line, r.Mod.Path)
+				break
+			}
+		}
+	}
+
+	var workspaceDirs, workspacePaths []string
+	for _, m := range workspaceModules(a) {
+		workspaceDirs = append(workspaceDirs, m.Dir)
+		workspacePaths = append(workspacePaths, m.Path)
+	}
+	for _, r := range f.Replace {
+		if !modfile.IsDirectoryPath(r.New.Path) {
+			continue
+		}
+		target := r.New.Path
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		target, err := filepath.Abs(target)
+		if err != nil || !slices.Contains(workspaceDirs, target) {
+			a.Errorf("%s:%d: local replace of %s to %s points outside the workspace",
+				goMod, r.Syntax.Start.Line, r.Old.Path, r.New.Path)
+		}
+	}
+
+	// Only direct requires are checked since querying the whole build list is slow, and
+	// modules that are developed locally are not published to check.
+	var paths []string
+	for _, r := range f.Require {
+		if r.Indirect || slices.Contains(workspacePaths, r.Mod.Path) ||
+			slices.ContainsFunc(f.Replace, func(rep *modfile.Replace) bool {
+				return rep.Old.Path == r.Mod.Path && modfile.IsDirectoryPath(rep.New.Path)
+			}) {
+			continue
+		}
+		paths = append(paths, r.Mod.Path)
+	}
+	if len(paths) == 0 {
+		return
+	}
+	// -e reports modules that could not be queried in their Error field instead of stopping
+	// at the first one. Querying needs the module proxy, so failures are only logged to keep
+	// working offline or with GOPROXY=off.
+	var out, stderr bytes.Buffer
+	c := exec.CommandContext(a.Context(), "go", append([]string{"list", "-e", "-m", "-u", "-retracted", "-json"}, paths...)...)
+	c.Dir = dir
+	c.Stdout = &out
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		a.Logf("%s: skipped checking requires for retractions and deprecations: %v: %s",
+			goMod, err, strings.TrimSpace(stderr.String()))
+		return
+	}
+	listed := map[string]listedModule{}
+	dec := json.NewDecoder(&out)
+	for {
+		var m listedModule
+		if err := dec.Decode(&m); err != nil {
+			if !errors.Is(err, io.EOF) {
+				a.Errorf("failed to parse go list output: %v", err)
+			}
+			break
+		}
+		listed[m.Path] = m
+	}
+	for _, r := range f.Require {
+		m, ok := listed[r.Mod.Path]
+		if !ok {
+			continue
+		}
+		if m.Error != nil {
+			a.Logf("%s:%d: skipped checking %s for retractions and deprecations: %s",
+				goMod, r.Syntax.Start.Line, r.Mod.Path, m.Error.Err)
+			continue
+		}
+		if m.Version != r.Mod.Version {
+			continue
+		}
+		if len(m.Retracted) > 0 {
+			a.Errorf("%s:%d: %s %s is retracted: %s", goMod, r.Syntax.Start.Line, r.Mod.Path, r.Mod.Version, m.Retracted[0])
+		}
+		if m.Deprecated != "" {
+			a.Errorf("%s:%d: %s is deprecated: %s", goMod, r.Syntax.Start.Line, r.Mod.Path, m.Deprecated)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package build
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goyek/goyek/v3"
+)
+
+func TestLintGoMod(t *testing.T) {
+	proxy := writeFileProxy(t, "example.com/dep", "v1.0.0", "v1.1.0")
+	latestMod := filepath.Join(strings.TrimPrefix(proxy, "file://"), "example.com", "dep", "@v", "v1.1.0.mod")
+	if err := os.WriteFile(latestMod, []byte("// Deprecated: use example.com/other.\nmodule example.com/dep\n\nretract v1.0.0 // broken\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOPROXY", proxy)
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("GOWORK", "off")
+
+	tests := []struct {
+		name      string
+		goMod     string
+		conf      config
+		proxyOff  bool
+		wantError []string
+		wantLog   []string
+	}{
+		{
+			name:  "clean",
+			goMod: "module example.com/app\n\ngo 1.25\n",
+		},
+		{
+			name:  "retracted and deprecated",
+			goMod: "module example.com/app\n\ngo 1.25\n\nrequire example.com/dep v1.0.0\n",
+			wantError: []string{
+				"go.mod:5: example.com/dep v1.0.0 is retracted: broken",
+				"go.mod:5: example.com/dep is deprecated: use example.com/other.",
+			},
+		},
+		{
+			name:      "indirect not queried",
+			goMod:     "module example.com/app\n\ngo 1.25\n\nrequire example.com/dep v1.0.0 // indirect\n",
+			wantError: nil,
+		},
+		{
+			name:    "lookup failure",
+			goMod:   "module example.com/app\n\ngo 1.25\n\nrequire example.com/missing v1.0.0\n",
+			wantLog: []string{"go.mod:5: skipped checking example.com/missing for retractions and deprecations"},
+		},
+		{
+			name:     "proxy off",
+			goMod:    "module example.com/app\n\ngo 1.25\n\nrequire example.com/dep v1.0.0\n",
+			proxyOff: true,
+			wantLog:  []string{"skipped checking example.com/dep for retractions and deprecations"},
+		},
+		{
+			name:      "banned",
+			goMod:     "module example.com/app\n\ngo 1.25\n\nrequire example.com/dep v1.1.0 // indirect\n",
+			conf:      config{bannedModules: []string{"example.com/*"}},
+			wantError: []string{"go.mod:5: module example.com/dep is banned"},
+		},
+		{
+			name:      "toolchain none",
+			goMod:     "module example.com/app\n\ngo 1.25\n\ntoolchain go1.25.1\n",
+			conf:      config{goModToolchain: "none"},
+			wantError: []string{"go.mod:5: toolchain directive is not allowed"},
+		},
+		{
+			name:      "toolchain mismatch",
+			goMod:     "module example.com/app\n\ngo 1.25\n\ntoolchain go1.25.1\n",
+			conf:      config{goModToolchain: "go1.25.2"},
+			wantError: []string{"go.mod:5: toolchain directive go1.25.1 must be go1.25.2"},
+		},
+		{
+			name:      "replace outside workspace",
+			goMod:     "module example.com/app\n\ngo 1.25\n\nreplace example.com/dep => ../dep\n",
+			wantError: []string{"go.mod:5: local replace of example.com/dep to ../dep points outside the workspace"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(tc.goMod), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			t.Chdir(dir)
+			if tc.proxyOff {
+				t.Setenv("GOPROXY", "off")
+				t.Setenv("GOMODCACHE", t.TempDir())
+			}
+
+			var out bytes.Buffer
+			res := goyek.NewRunner(func(a *goyek.A) {
+				lintGoMod(a, tc.conf, ".")
+			})(goyek.Input{Output: &out})
+			for _, want := range tc.wantLog {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output does not contain %q:\n%s", want, out.String())
+				}
+			}
+
+			if len(tc.wantError) == 0 {
+				if res.Status != goyek.StatusPassed {
+					t.Fatalf("status = %v, want passed, output:\n%s", res.Status, out.String())
+				}
+				return
+			}
+			if res.Status != goyek.StatusFailed {
+				t.Fatalf("status = %v, want failed, output:\n%s", res.Status, out.String())
+			}
+			for _, want := range tc.wantError {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output does not contain %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
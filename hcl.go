@@ -0,0 +1,42 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+)
+
+// execHCLFmt runs hclfmt with the flags on the HCL files, such as Terraform, under the
+// current directory. Like other tasks, it is run from the repository root if there is one.
+func execHCLFmt(a *goyek.A, conf config, runHCLFmt string, root string, target string, flags string) {
+	a.Helper()
+	files, err := findFiles(conf, func(path string) bool {
+		return slices.Contains([]string{".tf", ".tfvars", ".hcl"}, filepath.Ext(path))
+	})
+	if err != nil {
+		a.Error(err)
+		return
+	}
+	if len(files) == 0 {
+		a.Skip("no HCL files")
+	}
+
+	var opts []cmd.Option
+	if root != "" {
+		opts = append(opts, cmd.Dir(root))
+		for i, f := range files {
+			files[i] = filepath.Join(target, f)
+		}
+	}
+	for i, f := range files {
+		files[i] = fmt.Sprintf("%q", filepath.ToSlash(f))
+	}
+	// Without -w, hclfmt writes formatted files to stdout.
+	opts = append(opts, cmd.Stdout(io.Discard))
+	cmd.Exec(a, fmt.Sprintf("%s %s %s", runHCLFmt, flags, strings.Join(files, " ")), opts...)
+}
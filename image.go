@@ -0,0 +1,349 @@
+package build
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goyek/goyek/v3"
+)
+
+// Media types of the OCI image spec.
+const (
+	ociIndexMediaType    = "application/vnd.oci.image.index.v1+json"
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociConfigMediaType   = "application/vnd.oci.image.config.v1+json"
+	ociLayerMediaType    = "application/vnd.oci.image.layer.v1.tar+gzip"
+)
+
+// ImageBaseLayer returns an Option to set the base layer of images built by build-image, a
+// local tar or tar.gz of a root filesystem such as one exported from distroless static. It is
+// shared by all platforms, so it should not contain architecture-specific files. If not
+// provided, images only contain the binaries.
+func ImageBaseLayer(path string) Option {
+	return imageBaseLayer(path)
+}
+
+type imageBaseLayer string
+
+func (i imageBaseLayer) apply(c *config) {
+	c.imageBaseLayer = string(i)
+}
+
+// ImageName returns an Option to set the name of the image built by build-image, used for
+// the file name of the image and its reference. If not provided, the name of the current
+// directory is used.
+func ImageName(name string) Option {
+	return imageName(name)
+}
+
+type imageName string
+
+func (i imageName) apply(c *config) {
+	c.imageName = string(i)
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *ociPlatform      `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+// ociLayout accumulates the content-addressed blobs of an OCI image layout.
+type ociLayout struct {
+	blobs map[string][]byte
+	order []string
+}
+
+func (l *ociLayout) add(mediaType string, b []byte) ociDescriptor {
+	sum := sha256.Sum256(b)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if _, ok := l.blobs[digest]; !ok {
+		l.blobs[digest] = b
+		l.order = append(l.order, digest)
+	}
+	return ociDescriptor{MediaType: mediaType, Digest: digest, Size: int64(len(b))}
+}
+
+func (l *ociLayout) addJSON(mediaType string, v any) (ociDescriptor, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ociDescriptor{}, fmt.Errorf("build: encoding %s: %w", mediaType, err)
+	}
+	return l.add(mediaType, b), nil
+}
+
+// imagePath returns the folder build-image writes images to.
+func imagePath(conf config) string {
+	return filepath.Join(conf.artifactsPath, "image")
+}
+
+// buildImage writes an OCI image layout tarball with an image for each linux build-go
+// target, containing the binaries in /usr/local/bin on top of the base layer with the
+// binary of the first build package as the entrypoint.
+func buildImage(a *goyek.A, conf config) {
+	a.Helper()
+
+	name := conf.imageName
+	if name == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			a.Errorf("failed to get working directory: %v", err)
+			return
+		}
+		name = filepath.Base(wd)
+	}
+	// Tags may not contain +, which is used for build metadata in versions.
+	tag := strings.ReplaceAll(buildVersion(a, "."), "+", "-")
+	created := time.Unix(0, 0).UTC()
+	if ct, err := commitTime(a.Context(), "."); err == nil {
+		created = ct
+	}
+
+	layout := &ociLayout{blobs: map[string][]byte{}}
+	var baseLayer *ociDescriptor
+	var baseDiffID string
+	if conf.imageBaseLayer != "" {
+		layer, diffID, err := readBaseLayer(conf.imageBaseLayer)
+		if err != nil {
+			a.Error(err)
+			return
+		}
+		d := layout.add(ociLayerMediaType, layer)
+		baseLayer, baseDiffID = &d, diffID
+	}
+
+	dist := distPath(conf)
+	platforms, err := os.ReadDir(dist)
+	if err != nil {
+		a.Errorf("failed to read build-go output %s: %v", dist, err)
+		return
+	}
+	var manifests []ociDescriptor
+	for _, p := range platforms {
+		goos, goarch, _ := strings.Cut(p.Name(), "_")
+		if !p.IsDir() || goos != "linux" {
+			continue
+		}
+		var binaries []string
+		for _, pkg := range conf.buildPackages {
+			binaries = append(binaries, filepath.Join(dist, p.Name(), binaryName(pkg, goos)))
+		}
+		layer, diffID, err := binariesLayer(binaries, created)
+		if err != nil {
+			a.Error(err)
+			return
+		}
+
+		var layers []ociDescriptor
+		var diffIDs []string
+		if baseLayer != nil {
+			layers = append(layers, *baseLayer)
+			diffIDs = append(diffIDs, baseDiffID)
+		}
+		layers = append(layers, layout.add(ociLayerMediaType, layer))
+		diffIDs = append(diffIDs, diffID)
+
+		configDesc, err := layout.addJSON(ociConfigMediaType, map[string]any{
+			"created":      created.Format(time.RFC3339),
+			"architecture": goarch,
+			"os":           goos,
+			"config": map[string]any{
+				"Entrypoint": []string{path.Join("/usr/local/bin", binaryName(conf.buildPackages[0], goos))},
+				"Env":        []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"},
+			},
+			"rootfs": map[string]any{"type": "layers", "diff_ids": diffIDs},
+		})
+		if err != nil {
+			a.Error(err)
+			return
+		}
+		manifest, err := layout.addJSON(ociManifestMediaType, map[string]any{
+			"schemaVersion": 2,
+			"mediaType":     ociManifestMediaType,
+			"config":        configDesc,
+			"layers":        layers,
+		})
+		if err != nil {
+			a.Error(err)
+			return
+		}
+		manifest.Platform = &ociPlatform{Architecture: goarch, OS: goos}
+		manifests = append(manifests, manifest)
+	}
+	if len(manifests) == 0 {
+		a.Error("no linux build targets to build images for, add one with BuildTargets")
+		return
+	}
+
+	index, err := layout.addJSON(ociIndexMediaType, map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     ociIndexMediaType,
+		"manifests":     manifests,
+	})
+	if err != nil {
+		a.Error(err)
+		return
+	}
+	index.Annotations = map[string]string{
+		"org.opencontainers.image.ref.name": tag,
+		"io.containerd.image.name":          name + ":" + tag,
+	}
+
+	dir := imagePath(conf)
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // common for build artifacts
+		a.Errorf("failed to create %s: %v", dir, err)
+		return
+	}
+	out := filepath.Join(dir, name+".tar")
+	if err := writeOCILayout(out, layout, index, created); err != nil {
+		a.Error(err)
+		return
+	}
+	a.Logf("wrote image %s:%s to %s", name, tag, out)
+}
+
+// readBaseLayer returns the base layer at path compressed with gzip, and the digest of its
+// uncompressed content.
+func readBaseLayer(path string) ([]byte, string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("build: reading base layer: %w", err)
+	}
+	if len(b) < 2 || b[0] != 0x1f || b[1] != 0x8b {
+		return gzipLayer(b)
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, "", fmt.Errorf("build: reading base layer: %w", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, gr); err != nil { //nolint:gosec // base layer is trusted local file
+		return nil, "", fmt.Errorf("build: reading base layer: %w", err)
+	}
+	return b, "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// binariesLayer returns a gzip compressed layer with the binaries in /usr/local/bin, and the
+// digest of its uncompressed content.
+func binariesLayer(binaries []string, modTime time.Time) ([]byte, string, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, dir := range []string{"usr/", "usr/local/", "usr/local/bin/"} {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     dir,
+			Mode:     0o755,
+			ModTime:  modTime,
+			Format:   tar.FormatPAX,
+		}); err != nil {
+			return nil, "", fmt.Errorf("build: writing tar header: %w", err)
+		}
+	}
+	for _, b := range binaries {
+		info, err := os.Stat(b)
+		if err != nil {
+			return nil, "", fmt.Errorf("build: stat %s: %w", b, err)
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    "usr/local/bin/" + filepath.Base(b),
+			Mode:    0o755,
+			Size:    info.Size(),
+			ModTime: modTime,
+			Format:  tar.FormatPAX,
+		}); err != nil {
+			return nil, "", fmt.Errorf("build: writing tar header: %w", err)
+		}
+		if err := copyFile(tw, b); err != nil {
+			return nil, "", fmt.Errorf("build: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, "", fmt.Errorf("build: closing tar: %w", err)
+	}
+	return gzipLayer(buf.Bytes())
+}
+
+// gzipLayer compresses an uncompressed layer deterministically, returning it with the digest
+// of the uncompressed content.
+func gzipLayer(b []byte) ([]byte, string, error) {
+	sum := sha256.Sum256(b)
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(b); err != nil {
+		return nil, "", fmt.Errorf("build: compressing layer: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return nil, "", fmt.Errorf("build: compressing layer: %w", err)
+	}
+	return buf.Bytes(), "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// writeOCILayout writes the layout as a tarball at path with index as its only image.
+func writeOCILayout(path string, layout *ociLayout, index ociDescriptor, modTime time.Time) error {
+	indexJSON, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     ociIndexMediaType,
+		"manifests":     []ociDescriptor{index},
+	})
+	if err != nil {
+		return fmt.Errorf("build: encoding index: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("build: creating %s: %w", path, err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	write := func(name string, b []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(b)),
+			ModTime: modTime,
+			Format:  tar.FormatPAX,
+		}); err != nil {
+			return fmt.Errorf("build: writing tar header: %w", err)
+		}
+		if _, err := tw.Write(b); err != nil {
+			return fmt.Errorf("build: writing %s: %w", name, err)
+		}
+		return nil
+	}
+	if err := write("oci-layout", []byte(`{"imageLayoutVersion":"1.0.0"}`)); err != nil {
+		return err
+	}
+	if err := write("index.json", indexJSON); err != nil {
+		return err
+	}
+	for _, digest := range layout.order {
+		if err := write("blobs/sha256/"+strings.TrimPrefix(digest, "sha256:"), layout.blobs[digest]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("build: closing tar: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("build: closing %s: %w", path, err)
+	}
+	return nil
+}
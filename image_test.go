@@ -0,0 +1,251 @@
+package build
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/goyek/goyek/v3"
+)
+
+func sha256Digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// readTar returns the contents of the regular files in the tar, by name.
+func readTar(t *testing.T, r io.Reader) map[string][]byte {
+	t.Helper()
+	files := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[h.Name] = b
+	}
+}
+
+func gunzip(t *testing.T, b []byte) []byte {
+	t.Helper()
+	gr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func tarOf(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadBaseLayer(t *testing.T) {
+	dir := t.TempDir()
+	base := tarOf(t, map[string]string{"etc/passwd": "root:x:0:0::/root:/sbin/nologin\n"})
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write(base)
+	_ = gw.Close()
+	writeFiles(t, dir, map[string]string{"base.tar": string(base), "base.tar.gz": gz.String()})
+
+	for _, name := range []string{"base.tar", "base.tar.gz"} {
+		layer, diffID, err := readBaseLayer(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diffID != sha256Digest(base) {
+			t.Errorf("%s diff ID = %s, want digest of uncompressed tar", name, diffID)
+		}
+		if !bytes.Equal(gunzip(t, layer), base) {
+			t.Errorf("%s layer does not decompress to the base tar", name)
+		}
+	}
+	if layer, _, _ := readBaseLayer(filepath.Join(dir, "base.tar.gz")); !bytes.Equal(layer, gz.Bytes()) {
+		t.Error("compressed base layer was recompressed")
+	}
+
+	if _, _, err := readBaseLayer(filepath.Join(dir, "missing.tar")); err == nil {
+		t.Error("reading missing base layer succeeded, want error")
+	}
+}
+
+func TestBuildImage(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"out/dist/linux_amd64/server":       "amd64 server",
+		"out/dist/linux_amd64/worker":       "amd64 worker",
+		"out/dist/linux_arm64/server":       "arm64 server",
+		"out/dist/linux_arm64/worker":       "arm64 worker",
+		"out/dist/windows_amd64/server.exe": "windows server",
+		"out/dist/checksums.txt":            "",
+		"base.tar":                          string(tarOf(t, map[string]string{"etc/passwd": "root\n"})),
+	})
+	t.Chdir(dir)
+
+	conf := config{
+		artifactsPath:  "out",
+		buildPackages:  []string{"./cmd/server", "./cmd/worker"},
+		imageBaseLayer: "base.tar",
+		imageName:      "app",
+	}
+	build := func() []byte {
+		t.Helper()
+		var out bytes.Buffer
+		res := goyek.NewRunner(func(a *goyek.A) {
+			buildImage(a, conf)
+		})(goyek.Input{Output: &out})
+		if res.Status != goyek.StatusPassed {
+			t.Fatalf("buildImage status = %v, output:\n%s", res.Status, out.String())
+		}
+		b, err := os.ReadFile(filepath.Join("out", "image", "app.tar"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	image := build()
+	if !bytes.Equal(image, build()) {
+		t.Error("image is not reproducible")
+	}
+
+	files := readTar(t, bytes.NewReader(image))
+	if got := string(files["oci-layout"]); got != `{"imageLayoutVersion":"1.0.0"}` {
+		t.Errorf("oci-layout = %s", got)
+	}
+	for name, b := range files {
+		if digest, ok := strings.CutPrefix(name, "blobs/sha256/"); ok && sha256Digest(b) != "sha256:"+digest {
+			t.Errorf("blob %s has digest %s", name, sha256Digest(b))
+		}
+	}
+	// blob returns the content of the descriptor, checking its size.
+	blob := func(d ociDescriptor) []byte {
+		t.Helper()
+		b, ok := files["blobs/sha256/"+strings.TrimPrefix(d.Digest, "sha256:")]
+		if !ok {
+			t.Fatalf("blob %s missing", d.Digest)
+		}
+		if int64(len(b)) != d.Size {
+			t.Errorf("blob %s size = %d, descriptor size %d", d.Digest, len(b), d.Size)
+		}
+		return b
+	}
+	decode := func(b []byte, v any) {
+		t.Helper()
+		if err := json.Unmarshal(b, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var layoutIndex struct {
+		Manifests []ociDescriptor
+	}
+	decode(files["index.json"], &layoutIndex)
+	if len(layoutIndex.Manifests) != 1 {
+		t.Fatalf("index.json manifests = %v, want one", layoutIndex.Manifests)
+	}
+	ref := layoutIndex.Manifests[0]
+	if ref.MediaType != ociIndexMediaType || ref.Annotations["org.opencontainers.image.ref.name"] != "dev" ||
+		ref.Annotations["io.containerd.image.name"] != "app:dev" {
+		t.Errorf("index.json manifest = %+v", ref)
+	}
+
+	var index struct {
+		Manifests []ociDescriptor
+	}
+	decode(blob(ref), &index)
+	var platforms []string
+	for _, m := range index.Manifests {
+		platforms = append(platforms, m.Platform.OS+"/"+m.Platform.Architecture)
+
+		var manifest struct {
+			Config ociDescriptor
+			Layers []ociDescriptor
+		}
+		decode(blob(m), &manifest)
+		var config struct {
+			Architecture string
+			OS           string
+			Config       struct{ Entrypoint []string }
+			RootFS       struct {
+				DiffIDs []string `json:"diff_ids"`
+			}
+		}
+		decode(blob(manifest.Config), &config)
+		if config.OS != m.Platform.OS || config.Architecture != m.Platform.Architecture {
+			t.Errorf("config platform %s/%s, manifest platform %+v", config.OS, config.Architecture, m.Platform)
+		}
+		if !slices.Equal(config.Config.Entrypoint, []string{"/usr/local/bin/server"}) {
+			t.Errorf("entrypoint = %v", config.Config.Entrypoint)
+		}
+		if len(manifest.Layers) != 2 || len(config.RootFS.DiffIDs) != 2 {
+			t.Fatalf("layers = %v, diff_ids = %v, want base and binaries", manifest.Layers, config.RootFS.DiffIDs)
+		}
+		for i, l := range manifest.Layers {
+			if got := sha256Digest(gunzip(t, blob(l))); got != config.RootFS.DiffIDs[i] {
+				t.Errorf("layer %d diff_id = %s, want %s", i, config.RootFS.DiffIDs[i], got)
+			}
+		}
+		layer := readTar(t, bytes.NewReader(gunzip(t, blob(manifest.Layers[1]))))
+		for _, bin := range []string{"server", "worker"} {
+			if got, want := string(layer["usr/local/bin/"+bin]), m.Platform.Architecture+" "+bin; got != want {
+				t.Errorf("%s in %s layer = %q, want %q", bin, m.Platform.Architecture, got, want)
+			}
+		}
+	}
+	if !slices.Equal(platforms, []string{"linux/amd64", "linux/arm64"}) {
+		t.Errorf("platforms = %v, want linux only", platforms)
+	}
+}
+
+func TestBuildImageNoLinux(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"out/dist/darwin_arm64/server": "server"})
+	t.Chdir(dir)
+
+	var out bytes.Buffer
+	res := goyek.NewRunner(func(a *goyek.A) {
+		buildImage(a, config{artifactsPath: "out", buildPackages: []string{"./cmd/server"}})
+	})(goyek.Input{Output: &out})
+	if res.Status != goyek.StatusFailed || !strings.Contains(out.String(), "no linux build targets") {
+		t.Errorf("status = %v, output:\n%s", res.Status, out.String())
+	}
+}
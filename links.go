@@ -0,0 +1,315 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/goyek/goyek/v3"
+)
+
+var checkExternalLinks = flag.Bool("check-external-links", false, "lint-links: also check that external URLs respond successfully")
+
+// linkCacheTTL is how long a successful external link check is reused.
+const linkCacheTTL = 24 * time.Hour
+
+var (
+	mdInlineLink   = regexp.MustCompile(`!?\[[^\]]*\]\(\s*(?:<([^<>]*)>|([^)\s]+))(?:\s+["'(][^)]*)?\s*\)`)
+	mdRefLink      = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*(?:<([^<>]*)>|(\S+))(?:\s+.*)?$`)
+	mdAutoLink     = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+	mdCodeSpan     = regexp.MustCompile("`+[^`]*`+")
+	mdFence        = regexp.MustCompile("^\\s{0,3}(```|~~~)")
+	mdATXHeading   = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)(?:\s+#+)?\s*$`)
+	mdSetextMarker = regexp.MustCompile(`^\s{0,3}(=+|-+)\s*$`)
+	mdHTMLAnchor   = regexp.MustCompile(`<a\s[^>]*(?:name|id)="([^"]+)"`)
+	mdLinkText     = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+)
+
+// markdownLink is a link in a Markdown file.
+type markdownLink struct {
+	Line   int
+	Col    int
+	Target string
+}
+
+// markdownDoc is the links and anchors of a Markdown file.
+type markdownDoc struct {
+	links   []markdownLink
+	anchors map[string]bool
+}
+
+// parseMarkdownLinks returns the links and heading anchors of a Markdown document, ignoring
+// code blocks and code spans. Anchors are generated the same as GitHub.
+func parseMarkdownLinks(src []byte) markdownDoc {
+	doc := markdownDoc{anchors: map[string]bool{}}
+	slugs := map[string]int{}
+	addHeading := func(text string) {
+		slug := headingSlug(text)
+		if n := slugs[slug]; n > 0 {
+			doc.anchors[fmt.Sprintf("%s-%d", slug, n)] = true
+		} else {
+			doc.anchors[slug] = true
+		}
+		slugs[slug]++
+	}
+
+	fence := ""
+	prev := ""
+	for i, line := range strings.Split(string(src), "\n") {
+		if m := mdFence.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+			case fence == m[1]:
+				fence = ""
+			}
+			prev = ""
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		if m := mdATXHeading.FindStringSubmatch(line); m != nil {
+			addHeading(m[1])
+		} else if strings.TrimSpace(prev) != "" && mdSetextMarker.MatchString(line) && !strings.HasPrefix(strings.TrimSpace(prev), "-") {
+			addHeading(strings.TrimSpace(prev))
+		}
+		for _, m := range mdHTMLAnchor.FindAllStringSubmatch(line, -1) {
+			doc.anchors[m[1]] = true
+		}
+
+		// Blank out code spans so their content is not parsed, keeping columns.
+		text := mdCodeSpan.ReplaceAllStringFunc(line, func(s string) string { return strings.Repeat(" ", len(s)) })
+		for _, re := range []*regexp.Regexp{mdInlineLink, mdRefLink, mdAutoLink} {
+			for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+				// The target is the first matching group, an angle-bracketed target may contain spaces.
+				for g := 2; g < len(m); g += 2 {
+					if m[g] >= 0 {
+						doc.links = append(doc.links, markdownLink{Line: i + 1, Col: m[g] + 1, Target: text[m[g]:m[g+1]]})
+						break
+					}
+				}
+			}
+		}
+		prev = line
+	}
+	return doc
+}
+
+// headingSlug returns the anchor GitHub generates for a heading.
+func headingSlug(text string) string {
+	text = mdLinkText.ReplaceAllString(text, "$1")
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// lintLinks checks that relative links in Markdown files point at existing files and
+// anchors, and with -check-external-links that external URLs respond successfully. root is
+// the repository root, used for links starting with /.
+func lintLinks(a *goyek.A, conf config, root string, runReviewdog string) {
+	a.Helper()
+	files, err := findFiles(conf, func(path string) bool { return filepath.Ext(path) == ".md" })
+	if err != nil {
+		a.Error(err)
+		return
+	}
+	if len(files) == 0 {
+		a.Skip("no Markdown files")
+	}
+	if root == "" {
+		root = "."
+	}
+
+	docs := map[string]markdownDoc{}
+	parse := func(path string) (markdownDoc, error) {
+		if doc, ok := docs[path]; ok {
+			return doc, nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return markdownDoc{}, fmt.Errorf("build: reading %s: %w", path, err)
+		}
+		doc := parseMarkdownLinks(b)
+		docs[path] = doc
+		return doc, nil
+	}
+
+	var report bytes.Buffer
+	reportf := func(file string, l markdownLink, format string, args ...any) {
+		_, _ = fmt.Fprintf(&report, "%s:%d:%d: %s\n", filepath.ToSlash(file), l.Line, l.Col, fmt.Sprintf(format, args...))
+	}
+	external := map[string][]string{}
+	for _, file := range files {
+		doc, err := parse(file)
+		if err != nil {
+			a.Error(err)
+			continue
+		}
+		for _, l := range doc.links {
+			u, err := url.Parse(l.Target)
+			if err != nil {
+				reportf(file, l, "invalid link %s", l.Target)
+				continue
+			}
+			if u.Scheme != "" || u.Host != "" {
+				if u.Scheme == "http" || u.Scheme == "https" {
+					external[l.Target] = append(external[l.Target], fmt.Sprintf("%s:%d:%d", filepath.ToSlash(file), l.Line, l.Col))
+				}
+				continue
+			}
+
+			target := file
+			if u.Path != "" {
+				if strings.HasPrefix(u.Path, "/") {
+					target = filepath.Join(root, filepath.FromSlash(u.Path))
+				} else {
+					target = filepath.Join(filepath.Dir(file), filepath.FromSlash(u.Path))
+				}
+				if !fileExists(target) {
+					reportf(file, l, "link target %s does not exist", u.Path)
+					continue
+				}
+			}
+			if u.Fragment == "" || filepath.Ext(target) != ".md" {
+				continue
+			}
+			targetDoc, err := parse(target)
+			if err != nil {
+				a.Error(err)
+				continue
+			}
+			if !targetDoc.anchors[strings.ToLower(u.Fragment)] && !targetDoc.anchors[u.Fragment] {
+				reportf(file, l, "anchor #%s does not exist in %s", u.Fragment, filepath.ToSlash(target))
+			}
+		}
+	}
+
+	if *checkExternalLinks && len(external) > 0 {
+		cachePath := filepath.Join(conf.artifactsPath, "link-cache.json")
+		failures, err := checkURLs(a.Context(), http.DefaultClient, cachePath, slices.Sorted(maps.Keys(external)))
+		if err != nil {
+			a.Error(err)
+		}
+		for _, u := range slices.Sorted(maps.Keys(failures)) {
+			for _, pos := range external[u] {
+				_, _ = fmt.Fprintf(&report, "%s: link %s is broken: %s\n", pos, u, failures[u])
+			}
+		}
+	}
+
+	if report.Len() > 0 {
+		reportReviewdog(conf, a, runReviewdog, `-efm="%f:%l:%c: %m" -name=lint-links`, report.String())
+	}
+}
+
+// checkURLs requests each URL with client, returning the reason each one that does not
+// respond successfully failed. Successful results are cached in the JSON file at cachePath
+// so they are not requested again until they expire.
+func checkURLs(ctx context.Context, client *http.Client, cachePath string, urls []string) (map[string]string, error) {
+	cache := map[string]time.Time{}
+	if b, err := os.ReadFile(cachePath); err == nil {
+		// A corrupt cache is only a performance issue, so it is ignored.
+		_ = json.Unmarshal(b, &cache)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("build: reading link cache: %w", err)
+	}
+
+	var mu sync.Mutex
+	failures := map[string]string{}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 8)
+	now := time.Now()
+	// URLs to check are collected before starting any request since the requests update cache.
+	var pending []string
+	for _, u := range urls {
+		if checked, ok := cache[u]; ok && now.Sub(checked) < linkCacheTTL {
+			continue
+		}
+		pending = append(pending, u)
+	}
+	for _, u := range pending {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			err := checkURL(ctx, client, u)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[u] = err.Error()
+				delete(cache, u)
+			} else {
+				cache[u] = now
+			}
+		})
+	}
+	wg.Wait()
+
+	for u, checked := range cache {
+		if now.Sub(checked) >= linkCacheTTL {
+			delete(cache, u)
+		}
+	}
+	b, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return failures, fmt.Errorf("build: encoding link cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil { //nolint:gosec // common for build artifacts
+		return failures, fmt.Errorf("build: creating link cache directory: %w", err)
+	}
+	if err := os.WriteFile(cachePath, append(b, '\n'), 0o644); err != nil { //nolint:gosec // common for build artifacts
+		return failures, fmt.Errorf("build: writing link cache: %w", err)
+	}
+	return failures, nil
+}
+
+// checkURL requests u with HEAD, falling back to GET for servers that do not support it.
+func checkURL(ctx context.Context, client *http.Client, u string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	status := 0
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, u, nil)
+		if err != nil {
+			return fmt.Errorf("build: creating request: %w", err)
+		}
+		req.Header.Set("User-Agent", "go-build lint-links")
+		res, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("build: requesting %s: %w", u, err)
+		}
+		_ = res.Body.Close()
+		status = res.StatusCode
+		if status < 400 {
+			return nil
+		}
+		// Rate limiting does not mean the link is broken.
+		if status == http.StatusTooManyRequests {
+			return nil
+		}
+	}
+	return fmt.Errorf("build: status %d", status) //nolint:err113 // dynamic error
+}
@@ -0,0 +1,194 @@
+package build
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseMarkdownLinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		links   []markdownLink
+		anchors []string
+	}{
+		{
+			name: "inline and reference links",
+			src:  "See [a](a.md) and ![img](img.png \"title\").\n\n[ref]: https://example.com/x \"Title\"\n<https://example.com/auto>",
+			links: []markdownLink{
+				{Line: 1, Col: 9, Target: "a.md"},
+				{Line: 1, Col: 26, Target: "img.png"},
+				{Line: 3, Col: 8, Target: "https://example.com/x"},
+				{Line: 4, Col: 2, Target: "https://example.com/auto"},
+			},
+		},
+		{
+			name: "angle-bracket targets",
+			src:  "[c](<has space.md>) [d](<e.md> 'title')\n[ref]: <other file.md>",
+			links: []markdownLink{
+				{Line: 1, Col: 6, Target: "has space.md"},
+				{Line: 1, Col: 26, Target: "e.md"},
+				{Line: 2, Col: 9, Target: "other file.md"},
+			},
+		},
+		{
+			name: "fences",
+			src:  "```go\n[a](in-fence.md)\n~~~\n```\n[b](after.md)\n~~~~\n```\n# Not a heading\n~~~~",
+			links: []markdownLink{
+				{Line: 5, Col: 5, Target: "after.md"},
+			},
+		},
+		{
+			name: "code spans",
+			src:  "`[a](code.md)` and ``[b](`code`.md)`` but [c](c.md)",
+			links: []markdownLink{
+				{Line: 1, Col: 47, Target: "c.md"},
+			},
+		},
+		{
+			name:    "duplicate headings",
+			src:     "# Usage\n\n## Usage\n\nUsage\n-----\n\n- item\n---\n",
+			anchors: []string{"usage", "usage-1", "usage-2"},
+		},
+		{
+			name:    "html anchors and setext headings",
+			src:     "<a name=\"custom\"></a>\nSetext Title\n============\n",
+			anchors: []string{"custom", "setext-title"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := parseMarkdownLinks([]byte(tc.src))
+			if !reflect.DeepEqual(doc.links, tc.links) {
+				t.Errorf("links =\n%#v\nwant\n%#v", doc.links, tc.links)
+			}
+			anchors := make([]string, 0, len(doc.anchors))
+			for a := range doc.anchors {
+				anchors = append(anchors, a)
+			}
+			slices.Sort(anchors)
+			if len(anchors) == 0 {
+				anchors = nil
+			}
+			if !slices.Equal(anchors, tc.anchors) {
+				t.Errorf("anchors = %v, want %v", anchors, tc.anchors)
+			}
+		})
+	}
+}
+
+func TestHeadingSlug(t *testing.T) {
+	tests := []struct {
+		heading string
+		want    string
+	}{
+		{heading: "Usage", want: "usage"},
+		{heading: "Building and releasing", want: "building-and-releasing"},
+		{heading: "What's `new` in v1.2?", want: "whats-new-in-v12"},
+		{heading: "[go-build](https://example.com) options", want: "go-build-options"},
+		{heading: "snake_case and - dashes", want: "snake_case-and---dashes"},
+		{heading: "Über Größe", want: "über-größe"},
+	}
+	for _, tc := range tests {
+		if got := headingSlug(tc.heading); got != tc.want {
+			t.Errorf("headingSlug(%q) = %q, want %q", tc.heading, got, tc.want)
+		}
+	}
+}
+
+func TestCheckURLs(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string][]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path] = append(requests[r.URL.Path], r.Method)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/ok", "/expired", "/fresh":
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/rate-limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cachePath := filepath.Join(t.TempDir(), "out", "link-cache.json")
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	seeded := map[string]time.Time{
+		srv.URL + "/expired": now.Add(-linkCacheTTL - time.Hour),
+		srv.URL + "/fresh":   now.Add(-time.Hour),
+		srv.URL + "/unused":  now.Add(-2 * linkCacheTTL),
+	}
+	b, err := json.Marshal(seeded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cachePath, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	urls := []string{srv.URL + "/ok", srv.URL + "/missing", srv.URL + "/no-head", srv.URL + "/rate-limited", srv.URL + "/expired", srv.URL + "/fresh"}
+	failures, err := checkURLs(t.Context(), srv.Client(), cachePath, urls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 1 || !strings.Contains(failures[srv.URL+"/missing"], "404") {
+		t.Errorf("failures = %v, want only /missing with status 404", failures)
+	}
+	wantRequests := map[string][]string{
+		"/ok":           {http.MethodHead},
+		"/missing":      {http.MethodHead, http.MethodGet},
+		"/no-head":      {http.MethodHead, http.MethodGet},
+		"/rate-limited": {http.MethodHead},
+		"/expired":      {http.MethodHead},
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", requests, wantRequests)
+	}
+
+	cache := map[string]time.Time{}
+	b, err = os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &cache); err != nil {
+		t.Fatal(err)
+	}
+	var cached []string
+	for u, checked := range cache {
+		cached = append(cached, strings.TrimPrefix(u, srv.URL))
+		if now.Sub(checked) >= linkCacheTTL {
+			t.Errorf("cached %s at %v, want a time within the TTL", u, checked)
+		}
+	}
+	slices.Sort(cached)
+	if want := []string{"/expired", "/fresh", "/no-head", "/ok", "/rate-limited"}; !slices.Equal(cached, want) {
+		t.Errorf("cached = %v, want %v", cached, want)
+	}
+
+	// A second run only requests the failure, which was not cached.
+	clear(requests)
+	if _, err := checkURLs(t.Context(), srv.Client(), cachePath, urls); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"/missing": {http.MethodHead, http.MethodGet}}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
@@ -0,0 +1,130 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+)
+
+var writeToolVersions = flag.Bool("write-tool-versions", false, "outdated-tools: write latest tool versions to the tool versions file")
+
+// ToolVersionsFile returns an Option to set the path to a JSON file mapping tool names to
+// versions to use, which is updated by `outdated-tools -write-tool-versions`. Versions set
+// with ToolVersion take precedence over the file. If not provided, the default is
+// "tool-versions.json" in the build folder, which is only read if it exists.
+func ToolVersionsFile(path string) Option {
+	return toolVersionsFile(path)
+}
+
+type toolVersionsFile string
+
+func (t toolVersionsFile) apply(c *config) {
+	c.toolVersionsFile = string(t)
+}
+
+func readToolVersionsFile(path string) (map[string]string, error) {
+	if !fileExists(path) {
+		return map[string]string{}, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("build: reading tool versions file: %w", err)
+	}
+	versions := map[string]string{}
+	if err := json.Unmarshal(b, &versions); err != nil {
+		return nil, fmt.Errorf("build: parsing tool versions file %s: %w", path, err)
+	}
+	return versions, nil
+}
+
+func writeToolVersionsFile(path string, versions map[string]string) error {
+	b, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("build: encoding tool versions: %w", err)
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil { //nolint:gosec // checked in config file
+		return fmt.Errorf("build: writing tool versions file: %w", err)
+	}
+	return nil
+}
+
+// latestToolVersion queries the latest version of the module with the go command, which
+// respects GOPROXY including local file:// proxies.
+func latestToolVersion(a *goyek.A, module string) (string, bool) {
+	a.Helper()
+	var out bytes.Buffer
+	if !cmd.Exec(a, fmt.Sprintf("go list -m -f {{.Version}} %s@latest", module), cmd.Stdout(&out), cmd.Env("GOWORK", "off")) {
+		return "", false
+	}
+	return strings.TrimSpace(out.String()), true
+}
+
+func outdatedTools(a *goyek.A, conf config) {
+	a.Helper()
+	fileVersions, err := readToolVersionsFile(conf.toolVersionsFile)
+	if err != nil {
+		a.Error(err)
+		return
+	}
+
+	var outdated []string
+	latest := map[string]string{}
+	for _, name := range slices.Sorted(maps.Keys(tools)) {
+		t := tools[name]
+		if t.Module == "" {
+			continue
+		}
+		v, ok := latestToolVersion(a, t.Module)
+		if !ok {
+			continue
+		}
+		latest[name] = v
+		if v != toolVersion(t) {
+			outdated = append(outdated, name)
+		}
+	}
+
+	w := tabwriter.NewWriter(a.Output(), 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TOOL\tCURRENT\tLATEST\t")
+	for _, name := range slices.Sorted(maps.Keys(latest)) {
+		mark := ""
+		if slices.Contains(outdated, name) {
+			mark = "*"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, toolVersion(tools[name]), latest[name], mark)
+	}
+	_ = w.Flush()
+
+	if !*writeToolVersions {
+		if len(outdated) > 0 {
+			a.Logf("%d tools are outdated, run with -write-tool-versions to update %s", len(outdated), conf.toolVersionsFile)
+		}
+		return
+	}
+
+	if len(outdated) == 0 {
+		return
+	}
+	for _, name := range outdated {
+		if _, ok := conf.toolVersions[name]; ok {
+			a.Logf("%s version is set with the ToolVersion option which takes precedence over %s, update it manually",
+				name, conf.toolVersionsFile)
+			continue
+		}
+		fileVersions[name] = latest[name]
+	}
+	if err := writeToolVersionsFile(conf.toolVersionsFile, fileVersions); err != nil {
+		a.Error(err)
+		return
+	}
+	a.Logf("updated %s", conf.toolVersionsFile)
+}
@@ -0,0 +1,122 @@
+package build
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goyek/goyek/v3"
+)
+
+// writeFileProxy writes a GOPROXY file:// tree for a module with the versions.
+func writeFileProxy(t *testing.T, module string, versions ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	base := filepath.Join(dir, filepath.FromSlash(module), "@v")
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(base, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("list", strings.Join(versions, "\n")+"\n")
+	for _, v := range versions {
+		write(v+".info", `{"Version":"`+v+`","Time":"2026-01-01T00:00:00Z"}`)
+		write(v+".mod", "module "+module+"\n")
+	}
+	return "file://" + filepath.ToSlash(dir)
+}
+
+func TestReadWriteToolVersionsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool-versions.json")
+
+	versions, err := readToolVersionsFile(path)
+	if err != nil {
+		t.Fatalf("reading missing file: %v", err)
+	}
+	if len(versions) != 0 {
+		t.Errorf("missing file versions = %v, want empty", versions)
+	}
+
+	want := map[string]string{"a": "v1.0.0", "b": "v2.3.4"}
+	if err := writeToolVersionsFile(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readToolVersionsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) || got["a"] != want["a"] || got["b"] != want["b"] {
+		t.Errorf("round trip versions = %v, want %v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readToolVersionsFile(path); err == nil {
+		t.Error("reading invalid file succeeded, want error")
+	}
+}
+
+func TestOutdatedTools(t *testing.T) {
+	t.Setenv("GOPROXY", writeFileProxy(t, "example.com/tool", "v1.0.0", "v1.2.0"))
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOWORK", "off")
+	t.Chdir(t.TempDir())
+
+	oldTools := tools
+	t.Cleanup(func() { tools = oldTools })
+	tools = map[string]*Tool{
+		"tool":    {Name: "tool", Module: "example.com/tool", Version: "v1.0.0"},
+		"pinned":  {Name: "pinned", Module: "example.com/tool", Version: "v1.0.0"},
+		"current": {Name: "current", Module: "example.com/tool", Version: "v1.2.0"},
+	}
+
+	oldWrite := *writeToolVersions
+	t.Cleanup(func() { *writeToolVersions = oldWrite })
+
+	path := filepath.Join(t.TempDir(), "tool-versions.json")
+	conf := config{
+		toolVersionsFile: path,
+		toolVersions:     map[string]string{"pinned": "v1.0.0"},
+	}
+	run := func() string {
+		t.Helper()
+		var out bytes.Buffer
+		res := goyek.NewRunner(func(a *goyek.A) {
+			outdatedTools(a, conf)
+		})(goyek.Input{Output: &out})
+		if res.Status != goyek.StatusPassed {
+			t.Fatalf("outdatedTools status = %v, output:\n%s", res.Status, out.String())
+		}
+		return out.String()
+	}
+
+	*writeToolVersions = false
+	out := run()
+	if !strings.Contains(out, "2 tools are outdated") {
+		t.Errorf("output does not report outdated tools:\n%s", out)
+	}
+	if fileExists(path) {
+		t.Error("tool versions file written without -write-tool-versions")
+	}
+
+	*writeToolVersions = true
+	out = run()
+	got, err := readToolVersionsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got["tool"] != "v1.2.0" {
+		t.Errorf("written versions = %v, want only tool at v1.2.0", got)
+	}
+	if !strings.Contains(out, "pinned version is set with the ToolVersion option") {
+		t.Errorf("output does not explain skipped tool:\n%s", out)
+	}
+}
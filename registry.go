@@ -11,8 +11,18 @@ var (
 	testTasks     goyek.Deps
 
 	commandDownloads = map[string]struct{}{}
+	toolDownloads    = map[string]struct{}{}
+
+	tools        = map[string]*Tool{}
+	toolVersions = map[string]string{}
 )
 
+func init() {
+	for _, t := range defaultTools() {
+		tools[t.Name] = &t
+	}
+}
+
 // RegisterFormatTask adds a task that should be run during the format task.
 func RegisterFormatTask(task *goyek.DefinedTask) {
 	formatTasks = append(formatTasks, task)
@@ -35,9 +45,25 @@ func RegisterTestTask(task *goyek.DefinedTask) {
 
 // RegisterCommandDownloads registers the command to be downloaded by the download task.
 // It will be executed as is - to download Go tools, it should be a valid `go run` command
-// that will exit successfully.
+// that will exit successfully. For Go tools, prefer RegisterTool which also allows
+// overriding the version.
 func RegisterCommandDownloads(commands ...string) {
 	for _, module := range commands {
 		commandDownloads[module] = struct{}{}
 	}
 }
+
+// RegisterTool registers a Go tool to be downloaded by the download task. Tasks should
+// execute it using the command returned by ToolCommand, which will respect any version
+// set with the ToolVersion option. Registering a tool with the same name as an existing
+// one replaces it.
+func RegisterTool(tool Tool) {
+	tools[tool.Name] = &tool
+	toolDownloads[tool.Name] = struct{}{}
+}
+
+func registerToolDownloads(names ...string) {
+	for _, n := range names {
+		toolDownloads[n] = struct{}{}
+	}
+}
@@ -0,0 +1,444 @@
+package build
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goyek/goyek/v3"
+)
+
+// goyek's -dry-run skips task actions entirely, so release uses its own flag.
+var releaseDryRun = flag.Bool("release-dry-run", false, "release: create release artifacts locally without publishing them")
+
+// releaseManifest describes the artifacts of a release.
+type releaseManifest struct {
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Commit      string            `json:"commit"`
+	PreviousTag string            `json:"previousTag,omitempty"`
+	Changelog   string            `json:"changelog"`
+	Artifacts   []releaseArtifact `json:"artifacts"`
+}
+
+type releaseArtifact struct {
+	Name   string `json:"name"`
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// releasePath returns the folder release writes artifacts to.
+func releasePath(conf config) string {
+	return filepath.Join(conf.artifactsPath, "release")
+}
+
+func release(a *goyek.A, conf config) {
+	a.Helper()
+
+	dir := releasePath(conf)
+	if err := os.RemoveAll(dir); err != nil {
+		a.Errorf("failed to clean %s: %v", dir, err)
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // common for build artifacts
+		a.Errorf("failed to create %s: %v", dir, err)
+		return
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		a.Errorf("failed to get working directory: %v", err)
+		return
+	}
+	manifest := releaseManifest{
+		Name:      filepath.Base(wd),
+		Version:   buildVersion(a, "."),
+		Changelog: "CHANGELOG.md",
+	}
+	manifest.Commit, _ = git(a.Context(), ".", "rev-parse", "HEAD")
+	_, prefix := moduleTagPrefix(".")
+	// A tag must match the version the artifacts are named with, which is not the case for
+	// a dirty tree or a commit after the tag.
+	var tag string
+	if !*releaseDryRun {
+		tag = headTag(a.Context(), prefix)
+		switch {
+		case tag == "":
+			a.Error("HEAD is not tagged, tag the release or use -release-dry-run")
+			return
+		case tag != prefix+manifest.Version:
+			a.Errorf("tag %s on HEAD does not match release version %s, commit or stash changes before releasing",
+				tag, manifest.Version)
+			return
+		}
+	}
+
+	modTime := time.Unix(0, 0)
+	if ct, err := commitTime(a.Context(), "."); err == nil {
+		modTime = ct
+	}
+
+	// Include common documentation files in archives like most Go release tooling.
+	var extraFiles []string
+	for _, f := range []string{"LICENSE", "README.md"} {
+		if fileExists(f) {
+			extraFiles = append(extraFiles, f)
+		}
+	}
+
+	dist := distPath(conf)
+	platforms, err := os.ReadDir(dist)
+	if err != nil {
+		a.Errorf("failed to read build-go output %s: %v", dist, err)
+		return
+	}
+	var archives []string
+	for _, p := range platforms {
+		if !p.IsDir() {
+			continue
+		}
+		goos, goarch, _ := strings.Cut(p.Name(), "_")
+		ext := ".tar.gz"
+		if goos == "windows" {
+			ext = ".zip"
+		}
+		name := fmt.Sprintf("%s_%s_%s%s", manifest.Name, strings.TrimPrefix(manifest.Version, "v"), p.Name(), ext)
+		archive := filepath.Join(dir, name)
+		if err := writeArchive(archive, filepath.Join(dist, p.Name()), extraFiles, modTime); err != nil {
+			a.Error(err)
+			return
+		}
+		sum, err := sha256File(archive)
+		if err != nil {
+			a.Error(err)
+			return
+		}
+		info, err := os.Stat(archive)
+		if err != nil {
+			a.Errorf("failed to stat %s: %v", archive, err)
+			return
+		}
+		manifest.Artifacts = append(manifest.Artifacts, releaseArtifact{
+			Name: name, OS: goos, Arch: goarch, SHA256: sum, Size: info.Size(),
+		})
+		archives = append(archives, archive)
+	}
+	if err := writeChecksums(filepath.Join(dir, "SHA256SUMS"), dir, archives); err != nil {
+		a.Error(err)
+		return
+	}
+
+	prevTag := previousTag(a.Context(), prefix)
+	manifest.PreviousTag = prevTag
+	changelog := generateChangelog(a, conf, manifest.Version, prevTag)
+	if err := os.WriteFile(filepath.Join(dir, manifest.Changelog), []byte(changelog), 0o644); err != nil { //nolint:gosec // common for build artifacts
+		a.Errorf("failed to write changelog: %v", err)
+		return
+	}
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		a.Errorf("failed to encode manifest: %v", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), append(b, '\n'), 0o644); err != nil { //nolint:gosec // common for build artifacts
+		a.Errorf("failed to write manifest: %v", err)
+		return
+	}
+	a.Logf("wrote release %s to %s", manifest.Version, dir)
+
+	if *releaseDryRun {
+		a.Log("-release-dry-run set, not publishing")
+		return
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	repo := os.Getenv("GITHUB_REPOSITORY")
+	if token == "" || repo == "" {
+		a.Error("GITHUB_TOKEN and GITHUB_REPOSITORY must be set to publish, or use -release-dry-run")
+		return
+	}
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	assets := slices.Concat(archives, []string{filepath.Join(dir, "SHA256SUMS"), filepath.Join(dir, "manifest.json")})
+	if err := publishGitHubRelease(a.Context(), apiURL, repo, token, tag, changelog, assets); err != nil {
+		a.Error(err)
+		return
+	}
+	a.Logf("published release %s to %s", tag, repo)
+}
+
+// headTag returns the tag of the module with the tag prefix on the current commit, or an
+// empty string if there is none.
+func headTag(ctx context.Context, prefix string) string {
+	tag, _ := git(ctx, ".", "describe", "--tags", "--exact-match", "--match", prefix+"v[0-9]*", "HEAD")
+	return tag
+}
+
+// previousTag returns the latest tag of the module with the tag prefix before the current
+// commit, excluding a tag on the current commit since that is the release being made.
+func previousTag(ctx context.Context, prefix string) string {
+	rev := "HEAD"
+	if headTag(ctx, prefix) != "" {
+		rev = "HEAD^"
+	}
+	tag, _ := git(ctx, ".", "describe", "--tags", "--abbrev=0", "--match", prefix+"v[0-9]*", rev)
+	return tag
+}
+
+var conventionalCommit = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?: (.+)$`)
+
+// generateChangelog returns a Markdown changelog of conventional commits since prevTag
+// that touch the current module, excluding nested modules.
+func generateChangelog(a *goyek.A, conf config, version string, prevTag string) string {
+	a.Helper()
+	rng := "HEAD"
+	if prevTag != "" {
+		rng = prevTag + "..HEAD"
+	}
+	goMods, err := findFiles(conf, func(path string) bool { return filepath.Base(path) == "go.mod" })
+	if err != nil {
+		a.Error(err)
+	}
+	var modDirs []string
+	for _, goMod := range goMods {
+		modDirs = append(modDirs, filepath.Dir(goMod))
+	}
+	log, err := git(a.Context(), ".", append([]string{"log", "--format=%h%x1f%s%x1f%b%x1e", rng}, moduleLogPaths(".", modDirs)...)...)
+	if err != nil {
+		a.Errorf("failed to read git log: %v", err)
+	}
+
+	sections := []struct {
+		title   string
+		entries []string
+	}{
+		{title: "Breaking Changes"},
+		{title: "Features"},
+		{title: "Bug Fixes"},
+		{title: "Performance Improvements"},
+	}
+	for entry := range strings.SplitSeq(log, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(entry), "\x1f")
+		if len(fields) < 3 {
+			continue
+		}
+		hash, subject, body := fields[0], fields[1], fields[2]
+		m := conventionalCommit.FindStringSubmatch(subject)
+		if m == nil {
+			continue
+		}
+		typ, scope, breaking, desc := m[1], m[2], m[3] != "" || strings.Contains(body, "BREAKING CHANGE"), m[4]
+		line := "*   " + desc + " (" + hash + ")"
+		if scope != "" {
+			line = "*   **" + scope + ":** " + desc + " (" + hash + ")"
+		}
+		switch {
+		case breaking:
+			sections[0].entries = append(sections[0].entries, line)
+		case typ == "feat":
+			sections[1].entries = append(sections[1].entries, line)
+		case typ == "fix":
+			sections[2].entries = append(sections[2].entries, line)
+		case typ == "perf":
+			sections[3].entries = append(sections[3].entries, line)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# " + version + "\n")
+	for _, s := range sections {
+		if len(s.entries) == 0 {
+			continue
+		}
+		sb.WriteString("\n## " + s.title + "\n\n")
+		for _, e := range s.entries {
+			sb.WriteString(e + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// writeArchive writes the files in dir and extraFiles to path, as a zip if path ends with
+// .zip and otherwise as a tar.gz.
+func writeArchive(path string, dir string, extraFiles []string, modTime time.Time) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("build: reading %s: %w", dir, err)
+	}
+	files := make([]string, 0, len(entries)+len(extraFiles))
+	for _, e := range entries {
+		if !e.IsDir() {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	files = append(files, extraFiles...)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("build: creating %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(path, ".zip") {
+		err = writeZip(f, files, modTime)
+	} else {
+		err = writeTarGz(f, files, modTime)
+	}
+	if err != nil {
+		return fmt.Errorf("build: writing %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("build: closing %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeTarGz(w io.Writer, files []string, modTime time.Time) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", path, err)
+		}
+		hdr := &tar.Header{
+			Name:    filepath.Base(path),
+			Mode:    int64(info.Mode().Perm()),
+			Size:    info.Size(),
+			ModTime: modTime,
+			Format:  tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("writing tar header: %w", err)
+		}
+		if err := copyFile(tw, path); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("closing tar: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("closing gzip: %w", err)
+	}
+	return nil
+}
+
+func writeZip(w io.Writer, files []string, modTime time.Time) error {
+	zw := zip.NewWriter(w)
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", path, err)
+		}
+		hdr, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return fmt.Errorf("creating zip header: %w", err)
+		}
+		hdr.Method = zip.Deflate
+		hdr.Modified = modTime
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return fmt.Errorf("writing zip header: %w", err)
+		}
+		if err := copyFile(fw, path); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("closing zip: %w", err)
+	}
+	return nil
+}
+
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("copying %s: %w", path, err)
+	}
+	return nil
+}
+
+// publishGitHubRelease creates a GitHub release for tag with the notes and uploads assets.
+func publishGitHubRelease(ctx context.Context, apiURL string, repo string, token string, tag string, notes string, assets []string) error {
+	body, err := json.Marshal(map[string]string{"tag_name": tag, "name": tag, "body": notes})
+	if err != nil {
+		return fmt.Errorf("build: encoding release: %w", err)
+	}
+	var created struct {
+		UploadURL string `json:"upload_url"`
+	}
+	if err := githubRequest(ctx, http.MethodPost, strings.TrimSuffix(apiURL, "/")+"/repos/"+repo+"/releases",
+		token, "application/json", bytes.NewReader(body), &created); err != nil {
+		return err
+	}
+
+	// The upload URL is a URI template such as .../assets{?name,label}.
+	uploadURL, _, _ := strings.Cut(created.UploadURL, "{")
+	for _, asset := range assets {
+		f, err := os.Open(asset)
+		if err != nil {
+			return fmt.Errorf("build: opening %s: %w", asset, err)
+		}
+		err = githubRequest(ctx, http.MethodPost, uploadURL+"?name="+url.QueryEscape(filepath.Base(asset)),
+			token, "application/octet-stream", f, nil)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func githubRequest(ctx context.Context, method string, u string, token string, contentType string, body io.Reader, res any) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return fmt.Errorf("build: creating request: %w", err)
+	}
+	if f, ok := body.(*os.File); ok {
+		if info, err := f.Stat(); err == nil {
+			req.ContentLength = info.Size()
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", contentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("build: calling GitHub API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("build: GitHub API %s %s returned %s: %s", method, u, resp.Status, msg) //nolint:err113 // dynamic error
+	}
+	if res == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return fmt.Errorf("build: decoding GitHub API response: %w", err)
+	}
+	return nil
+}
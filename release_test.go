@@ -0,0 +1,157 @@
+package build
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/goyek/goyek/v3"
+)
+
+// initGitRepo creates a git repository in dir with the files committed.
+func initGitRepo(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	writeFiles(t, dir, files)
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "feat: initial")
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	if out, err := c.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestReleaseTagMismatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T, dir string)
+		want   string
+		noTags bool
+	}{
+		{
+			name:   "untagged",
+			noTags: true,
+			want:   "HEAD is not tagged",
+		},
+		{
+			name: "dirty",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				writeFiles(t, dir, map[string]string{"main.go": "package main\n\n// changed\n"})
+			},
+			want: "tag v1.0.0 on HEAD does not match release version v1.0.1-dev.0+g",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			initGitRepo(t, dir, map[string]string{"main.go": "package main\n"})
+			if !tc.noTags {
+				runGit(t, dir, "tag", "v1.0.0")
+			}
+			if tc.setup != nil {
+				tc.setup(t, dir)
+			}
+			t.Chdir(dir)
+
+			var out bytes.Buffer
+			res := goyek.NewRunner(func(a *goyek.A) {
+				release(a, config{artifactsPath: "out"})
+			})(goyek.Input{Output: &out})
+			if res.Status != goyek.StatusFailed || !strings.Contains(out.String(), tc.want) {
+				t.Errorf("status = %v, want failure containing %q, output:\n%s", res.Status, tc.want, out.String())
+			}
+		})
+	}
+}
+
+func TestReleaseTagsWithPrefix(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir, map[string]string{"go.mod": "module example.com/root\n", "sub/go.mod": "module example.com/root/sub\n"})
+	runGit(t, dir, "tag", "v1.0.0")
+	runGit(t, dir, "tag", "sub/v1.0.0")
+	writeFiles(t, dir, map[string]string{"sub/a.go": "package sub\n"})
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "feat: add a")
+	runGit(t, dir, "tag", "sub/v1.1.0")
+
+	tests := []struct {
+		dir      string
+		wantHead string
+		wantPrev string
+	}{
+		{dir: ".", wantHead: "", wantPrev: "v1.0.0"},
+		{dir: "sub", wantHead: "sub/v1.1.0", wantPrev: "sub/v1.0.0"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.dir, func(t *testing.T) {
+			t.Chdir(filepath.Join(dir, tc.dir))
+			_, prefix := moduleTagPrefix(".")
+			if got := headTag(t.Context(), prefix); got != tc.wantHead {
+				t.Errorf("headTag = %q, want %q", got, tc.wantHead)
+			}
+			if got := previousTag(t.Context(), prefix); got != tc.wantPrev {
+				t.Errorf("previousTag = %q, want %q", got, tc.wantPrev)
+			}
+		})
+	}
+}
+
+func TestGenerateChangelogModule(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir, map[string]string{
+		"go.mod":            "module example.com/root\n",
+		"sub/go.mod":        "module example.com/root/sub\n",
+		"sub/nested/go.mod": "module example.com/root/sub/nested\n",
+	})
+	for _, c := range []struct{ file, subject string }{
+		{file: "root.go", subject: "feat: root change"},
+		{file: "sub/sub.go", subject: "fix: sub change"},
+		{file: "sub/nested/nested.go", subject: "feat: nested change"},
+	} {
+		writeFiles(t, dir, map[string]string{c.file: "package x\n"})
+		runGit(t, dir, "add", "-A")
+		runGit(t, dir, "commit", "-q", "-m", c.subject)
+	}
+
+	tests := []struct {
+		dir  string
+		want []string
+	}{
+		{dir: ".", want: []string{"root change", "initial"}},
+		{dir: "sub", want: []string{"sub change", "initial"}},
+		{dir: "sub/nested", want: []string{"nested change", "initial"}},
+	}
+	all := []string{"root change", "sub change", "nested change", "initial"}
+	for _, tc := range tests {
+		t.Run(tc.dir, func(t *testing.T) {
+			t.Chdir(filepath.Join(dir, tc.dir))
+			var changelog string
+			var out bytes.Buffer
+			res := goyek.NewRunner(func(a *goyek.A) {
+				changelog = generateChangelog(a, config{artifactsPath: "out"}, "v1.0.0", "")
+			})(goyek.Input{Output: &out})
+			if res.Status != goyek.StatusPassed {
+				t.Fatalf("status = %v, want passed, output:\n%s", res.Status, out.String())
+			}
+			for _, entry := range all {
+				if got, want := strings.Contains(changelog, entry), slices.Contains(tc.want, entry); got != want {
+					t.Errorf("changelog contains %q = %v, want %v:\n%s", entry, got, want, changelog)
+				}
+			}
+		})
+	}
+}
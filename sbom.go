@@ -0,0 +1,367 @@
+package build
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"debug/buildinfo"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+	"golang.org/x/mod/modfile"
+)
+
+// sbomPackage is a Go module or tool listed in an SBOM.
+type sbomPackage struct {
+	Path    string
+	Version string
+	SHA256  string
+}
+
+func (p sbomPackage) purl() string {
+	if p.Version == "" {
+		return "pkg:golang/" + p.Path
+	}
+	return "pkg:golang/" + p.Path + "@" + strings.ReplaceAll(p.Version, "+", "%2B")
+}
+
+// sbom is the software bill of materials of a module or binary, written in both CycloneDX
+// and SPDX formats.
+type sbom struct {
+	name      string
+	binary    bool
+	subject   sbomPackage
+	packages  []sbomPackage
+	tools     []sbomPackage
+	timestamp time.Time
+}
+
+// addPackage adds p to the packages of s unless it is already listed, which happens when
+// several modules are replaced with the same one.
+func (s *sbom) addPackage(p sbomPackage) {
+	if slices.ContainsFunc(s.packages, func(o sbomPackage) bool { return o.purl() == p.purl() }) {
+		return
+	}
+	s.packages = append(s.packages, p)
+}
+
+// sbomPath returns the folder sbom writes documents to.
+func sbomPath(conf config) string {
+	return filepath.Join(conf.artifactsPath, "sbom")
+}
+
+func generateSBOMs(a *goyek.A, conf config) {
+	a.Helper()
+
+	dir := sbomPath(conf)
+	if err := os.RemoveAll(dir); err != nil {
+		a.Errorf("failed to clean %s: %v", dir, err)
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // common for build artifacts
+		a.Errorf("failed to create %s: %v", dir, err)
+		return
+	}
+
+	timestamp := time.Now().UTC()
+	if ct, err := commitTime(a.Context(), "."); err == nil {
+		timestamp = ct
+	}
+	tools := sbomTools()
+
+	buildDir, _ := filepath.Abs(conf.buildFolder)
+	for _, m := range workspaceModules(a) {
+		if m.Dir == buildDir {
+			continue
+		}
+		s, err := moduleSBOM(a, m, buildVersion(a, m.Dir))
+		if err != nil {
+			a.Error(err)
+			continue
+		}
+		s.tools = slices.Concat(tools, []sbomPackage{{Path: "go", Version: runtime.Version()}})
+		s.timestamp = timestamp
+		writeSBOM(a, dir, s)
+	}
+
+	dist := distPath(conf)
+	if !fileExists(dist) {
+		return
+	}
+	err := filepath.WalkDir(dist, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() == "checksums.txt" {
+			return err
+		}
+		s, err := binarySBOM(path)
+		if err != nil {
+			return err
+		}
+		s.tools = append(s.tools, tools...)
+		s.timestamp = timestamp
+		writeSBOM(a, dir, s)
+		return nil
+	})
+	if err != nil {
+		a.Errorf("failed to read build-go output %s: %v", dist, err)
+	}
+}
+
+// moduleSBOM lists the module graph of the module in dir, using its own go.mod rather than
+// the workspace.
+func moduleSBOM(a *goyek.A, m workspaceModule, version string) (sbom, error) {
+	a.Helper()
+	var out bytes.Buffer
+	if !cmd.Exec(a, "go list -e -m -json all", cmd.Dir(m.Dir), cmd.Env("GOWORK", "off"), cmd.Stdout(&out)) {
+		return sbom{}, fmt.Errorf("build: listing modules of %s failed", m.Path) //nolint:err113 // dynamic error
+	}
+	s := sbom{
+		name:    strings.ReplaceAll(m.Path, "/", "_"),
+		subject: sbomPackage{Path: m.Path, Version: version},
+	}
+	dec := json.NewDecoder(&out)
+	for {
+		var lm struct {
+			Path    string
+			Version string
+			Main    bool
+			Replace *struct {
+				Path    string
+				Version string
+			}
+		}
+		if err := dec.Decode(&lm); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return sbom{}, fmt.Errorf("build: parsing go list output: %w", err)
+		}
+		if lm.Main {
+			continue
+		}
+		p := sbomPackage{Path: lm.Path, Version: lm.Version}
+		// A module replaced with a local directory has no version of its own, so it keeps
+		// the required one.
+		if lm.Replace != nil && !modfile.IsDirectoryPath(lm.Replace.Path) {
+			p = sbomPackage{Path: lm.Replace.Path, Version: lm.Replace.Version}
+		}
+		s.addPackage(p)
+	}
+	return s, nil
+}
+
+// binarySBOM lists the modules compiled into a build-go binary from its embedded build info.
+func binarySBOM(path string) (sbom, error) {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return sbom{}, fmt.Errorf("build: reading build info of %s: %w", path, err)
+	}
+	sum, err := sha256File(path)
+	if err != nil {
+		return sbom{}, err
+	}
+	platform := filepath.Base(filepath.Dir(path))
+	s := sbom{
+		name:    strings.TrimSuffix(filepath.Base(path), ".exe") + "_" + platform,
+		binary:  true,
+		subject: sbomPackage{Path: info.Path, Version: info.Main.Version, SHA256: sum},
+		tools:   []sbomPackage{{Path: "go", Version: info.GoVersion}},
+	}
+	for _, d := range info.Deps {
+		if d.Replace != nil && !modfile.IsDirectoryPath(d.Replace.Path) {
+			d = d.Replace
+		}
+		s.addPackage(sbomPackage{Path: d.Path, Version: d.Version})
+	}
+	return s, nil
+}
+
+// sbomTools returns go-build and the registered tools with the versions used by the build.
+func sbomTools() []sbomPackage {
+	var res []sbomPackage
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, d := range info.Deps {
+			if d.Path == "github.com/curioswitch/go-build" {
+				res = append(res, sbomPackage{Path: d.Path, Version: d.Version})
+			}
+		}
+	}
+	var names []string
+	for name := range tools {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		t := tools[name]
+		res = append(res, sbomPackage{Path: t.Module, Version: toolVersion(t)})
+	}
+	return res
+}
+
+func writeSBOM(a *goyek.A, dir string, s sbom) {
+	a.Helper()
+	for ext, doc := range map[string]any{".cdx.json": cycloneDX(s), ".spdx.json": spdx(s)} {
+		path := filepath.Join(dir, s.name+ext)
+		b, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			a.Errorf("failed to encode %s: %v", path, err)
+			continue
+		}
+		if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil { //nolint:gosec // common for build artifacts
+			a.Errorf("failed to write %s: %v", path, err)
+			continue
+		}
+	}
+	a.Logf("wrote SBOM for %s to %s", s.subject.Path, filepath.Join(dir, s.name))
+}
+
+type cdxComponent struct {
+	Type    string    `json:"type"`
+	BOMRef  string    `json:"bom-ref,omitempty"`
+	Name    string    `json:"name"`
+	Version string    `json:"version,omitempty"`
+	PURL    string    `json:"purl,omitempty"`
+	Hashes  []cdxHash `json:"hashes,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// cycloneDX returns the CycloneDX 1.5 JSON document for s.
+func cycloneDX(s sbom) any {
+	component := func(typ string, p sbomPackage) cdxComponent {
+		c := cdxComponent{Type: typ, BOMRef: p.purl(), Name: p.Path, Version: p.Version, PURL: p.purl()}
+		if p.SHA256 != "" {
+			c.Hashes = []cdxHash{{Alg: "SHA-256", Content: p.SHA256}}
+		}
+		return c
+	}
+
+	subjectType := "library"
+	if s.binary {
+		subjectType = "application"
+	}
+	subject := component(subjectType, s.subject)
+	components := []cdxComponent{}
+	dependsOn := []string{}
+	for _, p := range s.packages {
+		components = append(components, component("library", p))
+		dependsOn = append(dependsOn, p.purl())
+	}
+	var tools []cdxComponent
+	for _, t := range s.tools {
+		c := component("application", t)
+		c.BOMRef = ""
+		tools = append(tools, c)
+	}
+
+	return map[string]any{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.5",
+		"version":     1,
+		"metadata": map[string]any{
+			"timestamp": s.timestamp.Format(time.RFC3339),
+			"tools":     map[string]any{"components": tools},
+			"component": subject,
+		},
+		"components":   components,
+		"dependencies": []cdxDependency{{Ref: subject.BOMRef, DependsOn: dependsOn}},
+	}
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdx returns the SPDX 2.3 JSON document for s.
+func spdx(s sbom) any {
+	pkg := func(id string, p sbomPackage) spdxPackage {
+		sp := spdxPackage{
+			Name:             p.Path,
+			SPDXID:           id,
+			VersionInfo:      p.Version,
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs: []spdxExternalRef{
+				{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: p.purl()},
+			},
+		}
+		if p.SHA256 != "" {
+			sp.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: p.SHA256}}
+		}
+		return sp
+	}
+
+	packages := []spdxPackage{pkg("SPDXRef-Package-0", s.subject)}
+	relationships := []spdxRelationship{
+		{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-Package-0"},
+	}
+	for i, p := range s.packages {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		packages = append(packages, pkg(id, p))
+		relationships = append(relationships, spdxRelationship{
+			SPDXElementID: "SPDXRef-Package-0", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: id,
+		})
+	}
+	creators := make([]string, 0, len(s.tools))
+	for _, t := range s.tools {
+		creators = append(creators, "Tool: "+t.Path+"-"+t.Version)
+	}
+
+	// The namespace must be unique per document, derive it from the content to keep
+	// documents reproducible.
+	h := sha256.Sum256([]byte(s.name + "\x00" + s.subject.Version + "\x00" + s.timestamp.String()))
+	return map[string]any{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              s.subject.Path,
+		"documentNamespace": "https://spdx.org/spdxdocs/" + s.name + "-" + hex.EncodeToString(h[:8]),
+		"creationInfo": map[string]any{
+			"created":  s.timestamp.Format(time.RFC3339),
+			"creators": creators,
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
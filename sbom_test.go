@@ -0,0 +1,143 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/goyek/goyek/v3"
+)
+
+func TestModuleSBOM(t *testing.T) {
+	t.Setenv("GOPROXY", writeFileProxy(t, "example.com/c", "v1.0.0"))
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-mod=mod")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.25\n\n" +
+			"require (\n\texample.com/a v1.0.0\n\texample.com/b v1.1.0\n\texample.com/local v0.0.0\n)\n\n" +
+			"replace (\n\texample.com/a => example.com/c v1.0.0\n\texample.com/b => example.com/c v1.0.0\n\texample.com/local => ./local\n)\n",
+		"local/go.mod": "module example.com/local\n\ngo 1.25\n",
+	})
+
+	var s sbom
+	var out bytes.Buffer
+	res := goyek.NewRunner(func(a *goyek.A) {
+		var err error
+		s, err = moduleSBOM(a, workspaceModule{Path: "example.com/app", Dir: dir}, "v1.2.3")
+		if err != nil {
+			a.Fatal(err)
+		}
+	})(goyek.Input{Output: &out})
+	if res.Status != goyek.StatusPassed {
+		t.Fatalf("status = %v, want passed, output:\n%s", res.Status, out.String())
+	}
+
+	want := []sbomPackage{
+		{Path: "example.com/c", Version: "v1.0.0"},
+		{Path: "example.com/local", Version: "v0.0.0"},
+	}
+	if !reflect.DeepEqual(s.packages, want) {
+		t.Errorf("packages = %v, want %v", s.packages, want)
+	}
+}
+
+func TestSBOMDocuments(t *testing.T) {
+	s := sbom{
+		name:    "server_linux-amd64",
+		binary:  true,
+		subject: sbomPackage{Path: "example.com/app/cmd/server", Version: "v1.2.3+dirty", SHA256: "abc123"},
+		packages: []sbomPackage{
+			{Path: "example.com/c", Version: "v1.0.0"},
+			{Path: "example.com/local", Version: "v0.0.0"},
+		},
+		tools:     []sbomPackage{{Path: "go", Version: "go1.25.1"}},
+		timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	decode := func(doc any) map[string]any {
+		t.Helper()
+		b, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res map[string]any
+		if err := json.Unmarshal(b, &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	t.Run("cyclonedx", func(t *testing.T) {
+		doc := decode(cycloneDX(s))
+		metadata := doc["metadata"].(map[string]any)
+		subject := metadata["component"].(map[string]any)
+		if subject["type"] != "application" || subject["purl"] != "pkg:golang/example.com/app/cmd/server@v1.2.3%2Bdirty" {
+			t.Errorf("component = %v, want application with escaped purl", subject)
+		}
+		if got := subject["hashes"].([]any)[0].(map[string]any)["content"]; got != "abc123" {
+			t.Errorf("component hash = %v, want abc123", got)
+		}
+		if metadata["timestamp"] != "2026-01-02T03:04:05Z" {
+			t.Errorf("timestamp = %v", metadata["timestamp"])
+		}
+
+		var refs []any
+		for _, c := range doc["components"].([]any) {
+			refs = append(refs, c.(map[string]any)["bom-ref"])
+		}
+		wantRefs := []any{"pkg:golang/example.com/c@v1.0.0", "pkg:golang/example.com/local@v0.0.0"}
+		if !reflect.DeepEqual(refs, wantRefs) {
+			t.Errorf("bom-refs = %v, want %v", refs, wantRefs)
+		}
+		deps := doc["dependencies"].([]any)[0].(map[string]any)
+		if deps["ref"] != subject["bom-ref"] || !reflect.DeepEqual(deps["dependsOn"], wantRefs) {
+			t.Errorf("dependencies = %v, want %v depending on %v", deps, subject["bom-ref"], wantRefs)
+		}
+		tool := metadata["tools"].(map[string]any)["components"].([]any)[0].(map[string]any)
+		if tool["name"] != "go" || tool["bom-ref"] != nil {
+			t.Errorf("tool = %v, want go without bom-ref", tool)
+		}
+	})
+
+	t.Run("spdx", func(t *testing.T) {
+		doc := decode(spdx(s))
+		if doc["spdxVersion"] != "SPDX-2.3" || doc["name"] != "example.com/app/cmd/server" {
+			t.Errorf("document = %v %v", doc["spdxVersion"], doc["name"])
+		}
+		var purls []any
+		for _, p := range doc["packages"].([]any) {
+			ref := p.(map[string]any)["externalRefs"].([]any)[0].(map[string]any)
+			purls = append(purls, ref["referenceLocator"])
+		}
+		wantPURLs := []any{
+			"pkg:golang/example.com/app/cmd/server@v1.2.3%2Bdirty",
+			"pkg:golang/example.com/c@v1.0.0",
+			"pkg:golang/example.com/local@v0.0.0",
+		}
+		if !reflect.DeepEqual(purls, wantPURLs) {
+			t.Errorf("purls = %v, want %v", purls, wantPURLs)
+		}
+		var rels []string
+		for _, r := range doc["relationships"].([]any) {
+			r := r.(map[string]any)
+			rels = append(rels, r["spdxElementId"].(string)+" "+r["relationshipType"].(string)+" "+r["relatedSpdxElement"].(string))
+		}
+		wantRels := []string{
+			"SPDXRef-DOCUMENT DESCRIBES SPDXRef-Package-0",
+			"SPDXRef-Package-0 DEPENDS_ON SPDXRef-Package-1",
+			"SPDXRef-Package-0 DEPENDS_ON SPDXRef-Package-2",
+		}
+		if !reflect.DeepEqual(rels, wantRels) {
+			t.Errorf("relationships = %v, want %v", rels, wantRels)
+		}
+		creators := doc["creationInfo"].(map[string]any)["creators"]
+		if !reflect.DeepEqual(creators, []any{"Tool: go-go1.25.1"}) {
+			t.Errorf("creators = %v", creators)
+		}
+		if again := decode(spdx(s)); again["documentNamespace"] != doc["documentNamespace"] {
+			t.Errorf("documentNamespace not reproducible: %v and %v", doc["documentNamespace"], again["documentNamespace"])
+		}
+	})
+}
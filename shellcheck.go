@@ -0,0 +1,101 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+)
+
+// ShellcheckRC returns an Option to set the shellcheckrc file used when lint-shell runs
+// shellcheck on shell scripts. If not provided, shellcheck looks for a .shellcheckrc in the
+// directories of the scripts and their parents as usual.
+func ShellcheckRC(path string) Option {
+	return shellcheckRC(path)
+}
+
+type shellcheckRC string
+
+func (s shellcheckRC) apply(c *config) {
+	c.shellcheckRC = string(s)
+}
+
+// maxShebangLength is the length of the first line read to find the interpreter of a script,
+// the limit of the Linux kernel.
+const maxShebangLength = 256
+
+// isShellScript returns whether file is a shell script, either by its extension
+// or, for files without one, by its shebang.
+func isShellScript(file string) bool {
+	switch filepath.Ext(file) {
+	case ".sh", ".bash":
+		return true
+	case "":
+	default:
+		return false
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	// Files without an extension are often binaries, so only read up to a bounded first line.
+	r := bufio.NewReaderSize(f, maxShebangLength)
+	if b, err := r.Peek(2); err != nil || string(b) != "#!" {
+		return false
+	}
+	line, err := r.ReadSlice('\n')
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return false
+	}
+	fields := strings.Fields(string(line[2:]))
+	// Skip env and its flags, e.g. `#!/usr/bin/env -S bash -e`.
+	if len(fields) > 0 && path.Base(fields[0]) == "env" {
+		fields = fields[1:]
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return false
+	}
+	switch path.Base(fields[0]) {
+	case "sh", "bash", "dash", "ksh":
+		return true
+	}
+	return false
+}
+
+// lintShellScripts runs shellcheck on the shell scripts in the repository.
+func lintShellScripts(a *goyek.A, conf config, runShellcheck string, runReviewdog string) {
+	a.Helper()
+	files, err := findFiles(conf, isShellScript)
+	if err != nil {
+		a.Error(err)
+		return
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	args := []string{"--format=gcc"}
+	if conf.shellcheckRC != "" {
+		args = append(args, "--rcfile="+conf.shellcheckRC)
+	}
+	for _, f := range files {
+		args = append(args, fmt.Sprintf("%q", filepath.ToSlash(f)))
+	}
+	var out bytes.Buffer
+	if cmd.Exec(a, runShellcheck+" "+strings.Join(args, " "), cmd.Stdout(&out)) || out.Len() == 0 {
+		return
+	}
+	reportReviewdog(conf, a, runReviewdog, `-efm="%f:%l:%c: %m" -name=shellcheck`, out.String())
+}
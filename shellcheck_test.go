@@ -0,0 +1,43 @@
+package build
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsShellScript(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"script.sh":    "echo hi\n",
+		"script.bash":  "",
+		"main.go":      "#!/bin/sh\n",
+		"sh":           "#!/bin/sh\necho hi\n",
+		"bash-env":     "#!/usr/bin/env bash\n",
+		"env-flags":    "#!/usr/bin/env -S bash -e\n",
+		"dash":         "#!/bin/dash",
+		"python":       "#!/usr/bin/env python3\n",
+		"no-shebang":   "echo hi\n",
+		"empty":        "",
+		"one-byte":     "#",
+		"only-shebang": "#!",
+		"binary":       "\x7fELF" + strings.Repeat("\x00", 1<<20),
+		"long-line":    "#!/bin/sh " + strings.Repeat("x", 1<<20),
+	}
+	writeFiles(t, dir, files)
+
+	want := map[string]bool{
+		"script.sh":   true,
+		"script.bash": true,
+		"sh":          true,
+		"bash-env":    true,
+		"env-flags":   true,
+		"dash":        true,
+		"long-line":   true,
+	}
+	for name := range files {
+		if got := isShellScript(filepath.Join(dir, name)); got != want[name] {
+			t.Errorf("isShellScript(%q) = %v, want %v", name, got, want[name])
+		}
+	}
+}
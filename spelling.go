@@ -0,0 +1,237 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+)
+
+// SpellingWordList returns an Option to set the project word list used by lint-spelling and
+// format-spelling. Each line is either a word that is not a misspelling, such as a product
+// name misspell would otherwise correct, or a "typo,fix" pair of an additional correction.
+// Empty lines and lines starting with # are ignored. If not provided, the default is
+// spelling-words.txt in the build folder, if it exists.
+func SpellingWordList(path string) Option {
+	return spellingWordList(path)
+}
+
+type spellingWordList string
+
+func (s spellingWordList) apply(c *config) {
+	c.spellingWordList = string(s)
+}
+
+// readSpellingWordList returns the ignored words and additional corrections in CSV format
+// from the word list at path. A missing file is not an error.
+func readSpellingWordList(path string) ([]string, string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("build: opening spelling word list: %w", err)
+	}
+	defer f.Close()
+
+	var ignores []string
+	var corrections strings.Builder
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if typo, fix, ok := strings.Cut(line, ","); ok {
+			_, _ = fmt.Fprintf(&corrections, "%s,%s\n", strings.TrimSpace(typo), strings.TrimSpace(fix))
+			continue
+		}
+		ignores = append(ignores, line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, "", fmt.Errorf("build: reading spelling word list: %w", err)
+	}
+	return ignores, corrections.String(), nil
+}
+
+// execMisspell runs misspell on Markdown files and comments and string literals of Go files.
+// Go comments are checked in misspell's Go mode so identifiers and import paths are never
+// changed. If fix is set, misspellings are corrected, only in comments for Go files, otherwise
+// they are reported through reviewdog in CI.
+func execMisspell(a *goyek.A, conf config, runMisspell string, runReviewdog string, fix bool) {
+	a.Helper()
+	files, err := findFiles(conf, func(path string) bool {
+		ext := filepath.Ext(path)
+		return ext == ".md" || ext == ".go"
+	})
+	if err != nil {
+		a.Error(err)
+		return
+	}
+	if len(files) == 0 {
+		a.Skip("no Markdown or Go files")
+	}
+	var markdown, goFiles []string
+	for _, f := range files {
+		if filepath.Ext(f) == ".go" {
+			goFiles = append(goFiles, f)
+		} else {
+			markdown = append(markdown, f)
+		}
+	}
+
+	wordList := conf.spellingWordList
+	if wordList == "" {
+		wordList = filepath.Join(conf.buildFolder, "spelling-words.txt")
+	}
+	ignores, corrections, err := readSpellingWordList(wordList)
+	if err != nil {
+		a.Error(err)
+		return
+	}
+
+	// -error fails on misspellings even when they are corrected.
+	args := []string{"-error"}
+	if fix {
+		args = []string{"-w"}
+	}
+	if len(ignores) > 0 {
+		args = append(args, fmt.Sprintf("-i %q", strings.Join(ignores, ",")))
+	}
+	if corrections != "" {
+		dict, err := os.CreateTemp("", "spelling-*.csv")
+		if err != nil {
+			a.Errorf("failed to create dictionary: %v", err)
+			return
+		}
+		defer os.Remove(dict.Name())
+		_, err = dict.WriteString(corrections)
+		if cerr := dict.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			a.Errorf("failed to write dictionary: %v", err)
+			return
+		}
+		args = append(args, fmt.Sprintf("-dict %q", filepath.ToSlash(dict.Name())))
+	}
+
+	groups := []struct {
+		source string
+		files  []string
+	}{
+		{source: "text", files: markdown},
+		{source: "go", files: goFiles},
+	}
+	// misspell only checks comments of Go files, so string literals are checked as text in
+	// copies with everything else blanked, keeping positions. They are not corrected since
+	// the copies are not written back.
+	stringsDir := ""
+	if !fix && len(goFiles) > 0 {
+		stringsDir, err = os.MkdirTemp("", "spelling-")
+		if err != nil {
+			a.Errorf("failed to create directory for string literals: %v", err)
+			return
+		}
+		defer os.RemoveAll(stringsDir)
+		var copies []string
+		for _, f := range goFiles {
+			src, err := os.ReadFile(f)
+			if err != nil {
+				a.Errorf("failed to read %s: %v", f, err)
+				return
+			}
+			lits, ok := goStringLiterals(src)
+			if !ok {
+				// Files that do not parse are reported by lint-go.
+				continue
+			}
+			dst := filepath.Join(stringsDir, f)
+			if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil { //nolint:gosec // common for build artifacts
+				a.Errorf("failed to create directory for string literals: %v", err)
+				return
+			}
+			if err := os.WriteFile(dst, lits, 0o644); err != nil { //nolint:gosec // common for build artifacts
+				a.Errorf("failed to write string literals of %s: %v", f, err)
+				return
+			}
+			copies = append(copies, dst)
+		}
+		groups = append(groups, struct {
+			source string
+			files  []string
+		}{source: "text", files: copies})
+	}
+
+	var out bytes.Buffer
+	for _, group := range groups {
+		if len(group.files) == 0 {
+			continue
+		}
+		command := append(slices.Clone(args), "-source="+group.source)
+		for _, f := range group.files {
+			command = append(command, fmt.Sprintf("%q", filepath.ToSlash(f)))
+		}
+		if fix {
+			cmd.Exec(a, runMisspell+" "+strings.Join(command, " "))
+			continue
+		}
+		cmd.Exec(a, runMisspell+" "+strings.Join(command, " "), cmd.Stdout(&out))
+	}
+	if fix || out.Len() == 0 {
+		return
+	}
+	report := out.String()
+	if stringsDir != "" {
+		report = strings.ReplaceAll(report, filepath.ToSlash(stringsDir)+"/", "")
+	}
+	reportReviewdog(conf, a, runReviewdog, "-f=misspell -name=misspell", report)
+}
+
+// goStringLiterals returns src with everything other than the contents of string literals
+// replaced with spaces, keeping line breaks so positions are unchanged. Import paths and
+// struct tags are not included since they cannot be corrected. It returns false if src is
+// not valid Go.
+func goStringLiterals(src []byte) ([]byte, bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+	skip := map[*ast.BasicLit]bool{}
+	for _, imp := range f.Imports {
+		skip[imp.Path] = true
+	}
+	res := bytes.Repeat([]byte(" "), len(src))
+	for i, b := range src {
+		if b == '\n' {
+			res[i] = b
+		}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			if n.Tag != nil {
+				skip[n.Tag] = true
+			}
+		case *ast.BasicLit:
+			if n.Kind == token.STRING && !skip[n] {
+				start := fset.Position(n.Pos()).Offset
+				copy(res[start:], src[start:start+len(n.Value)])
+			}
+		}
+		return true
+	})
+	return res, true
+}
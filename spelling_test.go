@@ -0,0 +1,32 @@
+package build
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoStringLiterals(t *testing.T) {
+	src := "package p\n\n" +
+		"import (\n\t\"fmt\"\n\tmsg \"example.com/recieve\"\n)\n\n" +
+		"// Comment with a recieve typo.\n" +
+		"type T struct {\n\tRecieve string `json:\"recieve\"`\n}\n\n" +
+		"func f() {\n\tfmt.Println(\"first recieve\", `raw\nrecieve`, msg.X, 'r')\n}\n"
+	got, ok := goStringLiterals([]byte(src))
+	if !ok {
+		t.Fatal("goStringLiterals() = false, want true")
+	}
+	if len(got) != len(src) || strings.Count(string(got), "\n") != strings.Count(src, "\n") {
+		t.Errorf("positions not kept, got %d bytes and %d lines, want %d and %d",
+			len(got), strings.Count(string(got), "\n"), len(src), strings.Count(src, "\n"))
+	}
+	if want := strings.Join(strings.Fields(`"first recieve" `+"`raw recieve`"), " "); strings.Join(strings.Fields(string(got)), " ") != want {
+		t.Errorf("goStringLiterals() kept %q, want %q", strings.Join(strings.Fields(string(got)), " "), want)
+	}
+	if i := strings.Index(src, `"first`); string(got[i:i+len(`"first recieve"`)]) != `"first recieve"` {
+		t.Errorf("string literal moved, got %q at offset %d", got[i:i+len(`"first recieve"`)], i)
+	}
+
+	if _, ok := goStringLiterals([]byte("package p\nfunc {")); ok {
+		t.Error("goStringLiterals() of invalid Go = true, want false")
+	}
+}
@@ -0,0 +1,342 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+)
+
+// SQLMigrationDirs returns an Option to set the names of directories containing SQL
+// migrations checked by lint-sql for unsafe patterns, including in their subdirectories such
+// as migrations/postgres. If not provided, the default is "migrations". SQL files in other
+// directories, such as sqlc queries, are only formatted.
+func SQLMigrationDirs(dirs ...string) Option {
+	return sqlMigrationDirs{dirs: dirs}
+}
+
+type sqlMigrationDirs struct {
+	dirs []string
+}
+
+func (s sqlMigrationDirs) apply(c *config) {
+	c.sqlMigrationDirs = append(c.sqlMigrationDirs, s.dirs...)
+}
+
+// sqlStatement is a statement of a SQL file, with comments removed.
+type sqlStatement struct {
+	Line   int
+	Text   string
+	Ignore []string
+}
+
+// sqlRule is a check for an unsafe pattern in migrations, which can be ignored for a
+// statement with a `-- lint-sql ignore=<name>` comment.
+type sqlRule struct {
+	name    string
+	match   *regexp.Regexp
+	unless  *regexp.Regexp
+	message string
+}
+
+var sqlRules = []sqlRule{
+	{
+		name:    "create-index-concurrently",
+		match:   regexp.MustCompile(`^CREATE (UNIQUE )?INDEX `),
+		unless:  regexp.MustCompile(`^CREATE (UNIQUE )?INDEX CONCURRENTLY `),
+		message: "CREATE INDEX without CONCURRENTLY blocks writes to the table, use CREATE INDEX CONCURRENTLY",
+	},
+	{
+		name:    "drop-index-concurrently",
+		match:   regexp.MustCompile(`^DROP INDEX `),
+		unless:  regexp.MustCompile(`^DROP INDEX CONCURRENTLY `),
+		message: "DROP INDEX without CONCURRENTLY blocks access to the table, use DROP INDEX CONCURRENTLY",
+	},
+	{
+		name:    "drop-column",
+		match:   regexp.MustCompile(`^ALTER TABLE .* DROP (COLUMN )?`),
+		unless:  regexp.MustCompile(`DROP (CONSTRAINT|DEFAULT|NOT NULL|IDENTITY|EXPRESSION)`),
+		message: "dropping a column breaks code still using it, deploy code that stops using it first and then ignore this rule",
+	},
+	{
+		name:    "drop-table",
+		match:   regexp.MustCompile(`^DROP TABLE `),
+		message: "dropping a table breaks code still using it, deploy code that stops using it first and then ignore this rule",
+	},
+	{
+		name:    "rename",
+		match:   regexp.MustCompile(`^ALTER TABLE .* RENAME `),
+		message: "renaming breaks code using the old name, add the new column or table and migrate to it instead",
+	},
+	{
+		name:    "alter-column-type",
+		match:   regexp.MustCompile(`^ALTER TABLE .* ALTER (COLUMN )?\S+ (SET DATA )?TYPE `),
+		message: "changing the type of a column rewrites the table and may break code using it, add a new column instead",
+	},
+	{
+		name:    "add-column-not-null",
+		match:   regexp.MustCompile(`^ALTER TABLE .* ADD (COLUMN )?.* NOT NULL`),
+		unless:  regexp.MustCompile(` DEFAULT `),
+		message: "adding a NOT NULL column without a default fails for existing rows and breaks inserts from running code",
+	},
+	{
+		name:    "constraint-not-valid",
+		match:   regexp.MustCompile(`^ALTER TABLE .* ADD (CONSTRAINT \S+ )?(FOREIGN KEY|CHECK)`),
+		unless:  regexp.MustCompile(` NOT VALID`),
+		message: "adding a constraint validates all rows while blocking writes, add it NOT VALID and VALIDATE CONSTRAINT separately",
+	},
+}
+
+var (
+	sqlIgnore     = regexp.MustCompile(`lint-sql\s+ignore=([a-z0-9-]+(?:,\s*[a-z0-9-]+)*)`)
+	sqlSpace      = regexp.MustCompile(`\s+`)
+	sqlString     = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlDollarTag  = regexp.MustCompile(`^\$[A-Za-z0-9_]*\$`)
+	sqlCreateTbl  = regexp.MustCompile(`^CREATE TABLE (IF NOT EXISTS )?(\S+)`)
+	sqlIndexTable = regexp.MustCompile(` ON (ONLY )?(\S+)`)
+	sqlAlterTable = regexp.MustCompile(`^ALTER TABLE (IF EXISTS )?(ONLY )?(\S+)`)
+)
+
+func isSQLFile(path string) bool {
+	return filepath.Ext(path) == ".sql"
+}
+
+// formatSQL formats SQL files with sql-formatter, which reads .sql-formatter.json for
+// configuration such as the SQL dialect. If check is set, files are not changed and
+// unformatted files fail the task. sql-formatter only accepts a single file so it is
+// installed once and executed for each file.
+func formatSQL(a *goyek.A, conf config, check bool) {
+	a.Helper()
+	files, err := findFiles(conf, isSQLFile)
+	if err != nil {
+		a.Error(err)
+		return
+	}
+	if len(files) == 0 {
+		a.Skip("no SQL files")
+	}
+	bin, err := os.MkdirTemp("", "sql-formatter")
+	if err != nil {
+		a.Errorf("failed to create temp directory: %v", err)
+		return
+	}
+	defer os.RemoveAll(bin)
+	runSQLFormatter, ok := installTool(a, ToolGoSQLFormatter, bin)
+	if !ok {
+		return
+	}
+	runSQLFormatter = fmt.Sprintf("%q", filepath.ToSlash(runSQLFormatter))
+	for _, f := range files {
+		if !check {
+			cmd.Exec(a, fmt.Sprintf("%s --fix %q", runSQLFormatter, filepath.ToSlash(f)))
+			continue
+		}
+		var out bytes.Buffer
+		if !cmd.Exec(a, fmt.Sprintf("%s %q", runSQLFormatter, filepath.ToSlash(f)), cmd.Stdout(&out)) {
+			continue
+		}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			a.Errorf("failed to read %s: %v", f, err)
+			continue
+		}
+		if strings.TrimSpace(string(b)) != strings.TrimSpace(out.String()) {
+			a.Errorf("%s is not formatted, run format-sql", f)
+		}
+	}
+}
+
+// lintSQL checks that SQL files are formatted and that migrations avoid unsafe patterns.
+func lintSQL(a *goyek.A, conf config, runReviewdog string) {
+	a.Helper()
+	formatSQL(a, conf, true)
+
+	dirs := conf.sqlMigrationDirs
+	if len(dirs) == 0 {
+		dirs = []string{"migrations"}
+	}
+	files, err := findFiles(conf, func(path string) bool {
+		return isSQLFile(path) && isMigrationFile(path, dirs)
+	})
+	if err != nil {
+		a.Error(err)
+		return
+	}
+	var report bytes.Buffer
+	for _, f := range files {
+		// Down migrations are expected to drop what up migrations add.
+		if strings.HasSuffix(f, ".down.sql") {
+			continue
+		}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			a.Errorf("failed to read %s: %v", f, err)
+			continue
+		}
+		for _, d := range lintMigration(splitSQL(string(b))) {
+			_, _ = fmt.Fprintf(&report, "%s:%d:1: %s %s\n", filepath.ToSlash(f), d.Line, d.Code, d.Message)
+		}
+	}
+	if report.Len() > 0 {
+		reportReviewdog(conf, a, runReviewdog, `-efm="%f:%l:%c: %m" -name=lint-sql`, report.String())
+	}
+}
+
+// isMigrationFile returns whether path is in one of the migration directories dirs, at any
+// depth, for example migrations/postgres/001.sql.
+func isMigrationFile(path string, dirs []string) bool {
+	parts := strings.Split(filepath.ToSlash(filepath.Dir(path)), "/")
+	return slices.ContainsFunc(parts, func(part string) bool { return slices.Contains(dirs, part) })
+}
+
+// lintMigration returns diagnostics for unsafe statements in a migration. Statements on
+// tables created in the same migration are allowed since the table is not in use yet.
+func lintMigration(stmts []sqlStatement) []lintDiagnostic {
+	var res []lintDiagnostic
+	created := map[string]bool{}
+	for _, s := range stmts {
+		// String literals are blanked so their content does not match rules.
+		text := sqlString.ReplaceAllString(strings.ToUpper(s.Text), "''")
+		if m := sqlCreateTbl.FindStringSubmatch(text); m != nil {
+			created[m[2]] = true
+			continue
+		}
+		if m := sqlIndexTable.FindStringSubmatch(text); m != nil && created[m[2]] && strings.HasPrefix(text, "CREATE") {
+			continue
+		}
+		if m := sqlAlterTable.FindStringSubmatch(text); m != nil && created[m[3]] {
+			continue
+		}
+		for _, r := range sqlRules {
+			if slices.Contains(s.Ignore, r.name) {
+				continue
+			}
+			if slices.ContainsFunc(sqlAlterActions(text), func(text string) bool {
+				return r.match.MatchString(text) && (r.unless == nil || !r.unless.MatchString(text))
+			}) {
+				res = append(res, lintDiagnostic{Line: s.Line, Code: r.name, Message: r.message})
+			}
+		}
+	}
+	return res
+}
+
+// sqlAlterActions splits an ALTER TABLE statement with multiple comma-separated actions
+// into a statement for each action, so rules only see a single action. Other statements
+// are returned as is.
+func sqlAlterActions(text string) []string {
+	m := sqlAlterTable.FindStringIndex(text)
+	if m == nil {
+		return []string{text}
+	}
+	header, rest := text[:m[1]], text[m[1]:]
+	var res []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			res = append(res, header+" "+strings.TrimSpace(rest[start:i]))
+			start = i + 1
+		}
+	}
+	return append(res, header+" "+strings.TrimSpace(rest[start:]))
+}
+
+// splitSQL splits a SQL script into statements, removing comments and normalizing
+// whitespace. Semicolons in strings, quoted identifiers and dollar-quoted bodies do not
+// end statements.
+func splitSQL(src string) []sqlStatement {
+	var res []sqlStatement
+	var cur strings.Builder
+	var ignore []string
+	line, start := 1, 0
+	flush := func() {
+		text := strings.TrimSpace(sqlSpace.ReplaceAllString(cur.String(), " "))
+		if text != "" {
+			res = append(res, sqlStatement{Line: start, Text: text, Ignore: ignore})
+		}
+		cur.Reset()
+		ignore = nil
+		start = 0
+	}
+	comment := func(c string) {
+		if m := sqlIgnore.FindStringSubmatch(c); m != nil {
+			for _, r := range strings.Split(m[1], ",") {
+				ignore = append(ignore, strings.TrimSpace(r))
+			}
+		}
+		line += strings.Count(c, "\n")
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		rest := src[i:]
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			comment(rest[:end])
+			i += end
+			continue
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest, "*/")
+			if end < 0 {
+				end = len(rest) - 2
+			}
+			comment(rest[:end+2])
+			cur.WriteByte(' ')
+			i += end + 2
+			continue
+		case c == ';':
+			flush()
+			i++
+			continue
+		}
+
+		if start == 0 && c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			start = line
+		}
+		n := 1
+		switch {
+		case c == '\'' || c == '"':
+			if end := strings.IndexByte(rest[1:], c); end >= 0 {
+				n = end + 2
+			} else {
+				n = len(rest)
+			}
+		case c == '$':
+			if tag := sqlDollarTag.FindString(rest); tag != "" {
+				if end := strings.Index(rest[len(tag):], tag); end >= 0 {
+					n = len(tag) + end + len(tag)
+				} else {
+					n = len(rest)
+				}
+			}
+		}
+		line += strings.Count(rest[:n], "\n")
+		cur.WriteString(rest[:n])
+		i += n
+	}
+	flush()
+	return res
+}
@@ -0,0 +1,138 @@
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestSplitSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []sqlStatement
+	}{
+		{
+			name: "statements and line numbers",
+			src:  "CREATE TABLE a (id int);\n\n\nCREATE TABLE b (\n  id int\n);\nSELECT 1",
+			want: []sqlStatement{
+				{Line: 1, Text: "CREATE TABLE a (id int)"},
+				{Line: 4, Text: "CREATE TABLE b ( id int )"},
+				{Line: 7, Text: "SELECT 1"},
+			},
+		},
+		{
+			name: "comments",
+			src:  "-- leading; comment\nSELECT 1; /* block;\ncomment */ SELECT 2 -- trailing;\n;\n/* only a comment */",
+			want: []sqlStatement{
+				{Line: 2, Text: "SELECT 1"},
+				{Line: 3, Text: "SELECT 2"},
+			},
+		},
+		{
+			name: "quotes",
+			src:  "INSERT INTO t VALUES ('a;b', \"c;d\");\nSELECT 'it''s';",
+			want: []sqlStatement{
+				{Line: 1, Text: `INSERT INTO t VALUES ('a;b', "c;d")`},
+				{Line: 2, Text: "SELECT 'it''s'"},
+			},
+		},
+		{
+			name: "dollar quoting",
+			src:  "CREATE FUNCTION f() RETURNS int AS $$\nBEGIN\n  RETURN 1;\nEND;\n$$ LANGUAGE plpgsql;\nDO $body$ BEGIN PERFORM 1; END $body$;\nSELECT $1;",
+			want: []sqlStatement{
+				{Line: 1, Text: "CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql"},
+				{Line: 6, Text: "DO $body$ BEGIN PERFORM 1; END $body$"},
+				{Line: 7, Text: "SELECT $1"},
+			},
+		},
+		{
+			name: "ignore directives",
+			src:  "-- lint-sql ignore=drop-column, rename\nALTER TABLE a DROP COLUMN b;\nDROP TABLE c; -- lint-sql ignore=drop-table\n/* lint-sql ignore=drop-table */ DROP TABLE d;",
+			want: []sqlStatement{
+				{Line: 2, Text: "ALTER TABLE a DROP COLUMN b", Ignore: []string{"drop-column", "rename"}},
+				{Line: 3, Text: "DROP TABLE c"},
+				{Line: 4, Text: "DROP TABLE d", Ignore: []string{"drop-table", "drop-table"}},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := splitSQL(tc.src); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("splitSQL() =\n%#v\nwant\n%#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLintMigration(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		// want is the line and rule of each diagnostic, for example "1:drop-table".
+		want []string
+	}{
+		{name: "create index", src: "CREATE INDEX i ON t (a);\ncreate unique index concurrently j on t (b);", want: []string{"1:create-index-concurrently"}},
+		{name: "drop index", src: "DROP INDEX i;\nDROP INDEX CONCURRENTLY j;", want: []string{"1:drop-index-concurrently"}},
+		{name: "drop column", src: "ALTER TABLE t DROP COLUMN a;\nALTER TABLE t DROP b;", want: []string{"1:drop-column", "2:drop-column"}},
+		{name: "drop constraint", src: "ALTER TABLE t DROP CONSTRAINT c;\nALTER TABLE t ALTER COLUMN a DROP DEFAULT;"},
+		{name: "drop constraint and column", src: "ALTER TABLE t DROP CONSTRAINT c, DROP COLUMN y;", want: []string{"1:drop-column"}},
+		{name: "drop table", src: "DROP TABLE t;", want: []string{"1:drop-table"}},
+		{name: "rename", src: "ALTER TABLE t RENAME COLUMN a TO b;", want: []string{"1:rename"}},
+		{name: "alter column type", src: "ALTER TABLE t ALTER COLUMN a TYPE bigint;\nALTER TABLE t ALTER a SET DATA TYPE text;", want: []string{"1:alter-column-type", "2:alter-column-type"}},
+		{name: "add column not null", src: "ALTER TABLE t ADD COLUMN a int NOT NULL;\nALTER TABLE t ADD COLUMN b int NOT NULL DEFAULT 0;", want: []string{"1:add-column-not-null"}},
+		{
+			name: "add columns with and without default",
+			src:  "ALTER TABLE t ADD COLUMN a int NOT NULL DEFAULT 0, ADD COLUMN b numeric(10, 2) NOT NULL;",
+			want: []string{"1:add-column-not-null"},
+		},
+		{name: "constraint", src: "ALTER TABLE t ADD CONSTRAINT c FOREIGN KEY (a) REFERENCES u (id);\nALTER TABLE t ADD CHECK (a > 0) NOT VALID;", want: []string{"1:constraint-not-valid"}},
+		{
+			name: "table created in migration",
+			src:  "CREATE TABLE IF NOT EXISTS t (id int);\nCREATE INDEX i ON t (id);\nALTER TABLE t ADD COLUMN a int NOT NULL;\nCREATE INDEX j ON other (id);\nALTER TABLE ONLY other DROP COLUMN a;",
+			want: []string{"4:create-index-concurrently", "5:drop-column"},
+		},
+		{
+			name: "ignored",
+			src:  "-- lint-sql ignore=drop-column\nALTER TABLE t DROP COLUMN a, RENAME TO u;",
+			want: []string{"2:rename"},
+		},
+		{name: "strings are not actions", src: "ALTER TABLE t ADD COLUMN a text NOT NULL DEFAULT 'x, DROP COLUMN y';"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, d := range lintMigration(splitSQL(tc.src)) {
+				got = append(got, fmt.Sprintf("%d:%s", d.Line, d.Code))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("diagnostics = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsMigrationFile(t *testing.T) {
+	tests := []struct {
+		path string
+		dirs []string
+		want bool
+	}{
+		{path: "migrations/001.sql", dirs: []string{"migrations"}, want: true},
+		{path: "db/migrations/001.sql", dirs: []string{"migrations"}, want: true},
+		{path: "migrations/postgres/001.sql", dirs: []string{"migrations"}, want: true},
+		{path: "db/migrations/postgres/2026/001.sql", dirs: []string{"migrations"}, want: true},
+		{path: "queries/users.sql", dirs: []string{"migrations"}, want: false},
+		{path: "schema.sql", dirs: []string{"migrations"}, want: false},
+		{path: "old-migrations/001.sql", dirs: []string{"migrations"}, want: false},
+		{path: "migrations.sql", dirs: []string{"migrations"}, want: false},
+		{path: "db/schema/001.sql", dirs: []string{"migrations", "schema"}, want: true},
+	}
+	for _, tc := range tests {
+		if got := isMigrationFile(filepath.FromSlash(tc.path), tc.dirs); got != tc.want {
+			t.Errorf("isMigrationFile(%q, %v) = %v, want %v", tc.path, tc.dirs, got, tc.want)
+		}
+	}
+}
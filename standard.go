@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -23,22 +24,26 @@ func DefineTasks(opts ...Option) {
 	_ = flag.Lookup("v").Value.Set("true")
 
 	conf := config{
-		artifactsPath:   "out",
-		buildFolder:     "build",
-		verActionlint:   verActionlint,
-		verGolangCILint: verGolangCILint,
-		verGoPrettier:   verGoPrettier,
-		verGoShellcheck: verGoShellcheck,
-		verGoRumdl:      verGoRumdl,
-		verGoTestsum:    verGoTestsum,
-		verGoTombi:      verGoTombi,
-		verGoRyl:        verGoRyl,
-		verPinact:       verPinact,
-		verReviewdog:    verReviewdog,
+		artifactsPath: "out",
+		buildFolder:   "build",
 	}
 	for _, o := range opts {
 		o.apply(&conf)
 	}
+	if conf.toolVersionsFile == "" {
+		conf.toolVersionsFile = filepath.Join(conf.buildFolder, "tool-versions.json")
+	}
+	fileVersions, err := readToolVersionsFile(conf.toolVersionsFile)
+	if err != nil {
+		_, _ = fmt.Fprintln(goyek.Output(), err)
+		os.Exit(2)
+	}
+	for name, version := range fileVersions {
+		toolVersions[name] = version
+	}
+	for name, version := range conf.toolVersions {
+		toolVersions[name] = version
+	}
 
 	var golangciTargets []string
 	// Rare to not have a go.mod, except for a monorepo root where it's common.
@@ -49,30 +54,42 @@ func DefineTasks(opts ...Option) {
 	}
 	// Uses of go-build will very commonly have a build folder, if it is also a module,
 	// then let's automatically run checks on it.
-	if fileExists(filepath.Join(conf.buildFolder, "go.mod")) {
+	hasBuildGoMod := fileExists(filepath.Join(conf.buildFolder, "go.mod"))
+	if hasBuildGoMod {
 		golangciTargets = append(golangciTargets, "./"+conf.buildFolder)
 	}
 
 	root, target := pathRelativeToRoot()
 
-	runActionlint := "go run github.com/rhysd/actionlint/cmd/actionlint@" + conf.verActionlint
-	runGolangCILint := "go run github.com/golangci/golangci-lint/v2/cmd/golangci-lint@" + conf.verGolangCILint
-	runGoPrettier := "go run github.com/wasilibs/go-prettier/v3/cmd/prettier@" + conf.verGoPrettier
-	runGoShellcheck := "go run github.com/wasilibs/go-shellcheck/cmd/shellcheck@" + conf.verGoShellcheck
-	runGoRumdl := "go run github.com/wasilibs/go-rumdl/cmd/rumdl@" + conf.verGoRumdl
-	runGoRyl := "go run github.com/wasilibs/go-ryl/cmd/ryl@" + conf.verGoRyl
-	runGoTestsum := "go run gotest.tools/gotestsum@" + conf.verGoTestsum
-	runGoTombi := "go run github.com/wasilibs/go-tombi/cmd/tombi@" + conf.verGoTombi
-	runPinact := "go run github.com/suzuki-shunsuke/pinact/v4/cmd/pinact@" + conf.verPinact
-	runReviewDog := "go run github.com/reviewdog/reviewdog/cmd/reviewdog@" + conf.verReviewdog
-
-	if !conf.excluded("format-go") {
-		RegisterCommandDownloads(runGolangCILint)
+	runActionlint := ToolCommand(ToolActionlint)
+	runApidiff := ToolCommand(ToolApidiff)
+	runGolangCILint := ToolCommand(ToolGolangCILint)
+	runGoPrettier := ToolCommand(ToolGoPrettier)
+	runGoShellcheck := ToolCommand(ToolGoShellcheck)
+	runGoRumdl := ToolCommand(ToolGoRumdl)
+	runGoRyl := ToolCommand(ToolGoRyl)
+	runGoTestsum := ToolCommand(ToolGoTestsum)
+	runGoTombi := ToolCommand(ToolGoTombi)
+	runHCLFmt := ToolCommand(ToolHCLFmt)
+	runMisspell := ToolCommand(ToolMisspell)
+	runPinact := ToolCommand(ToolPinact)
+	runReviewDog := ToolCommand(ToolReviewdog)
+
+	if conf.shouldDefine("format-go") {
+		explainGoDetection("format-go", conf, hasGoMod, hasBuildGoMod)
+		registerToolDownloads(ToolGolangCILint)
 		RegisterFormatTask(goyek.Define(goyek.Task{
 			Name:     "format-go",
 			Usage:    "Formats Go code.",
 			Parallel: true,
 			Action: func(a *goyek.A) {
+				if conf.allModules {
+					forEachModule(a, conf, selectedModDirs(a), false, func(a *goyek.A, dir string) {
+						cmd.Exec(a, runGolangCILint+" fmt ./...", cmd.Dir(dir))
+						cmd.Exec(a, "go mod tidy", cmd.Dir(dir))
+					})
+					return
+				}
 				cmd.Exec(a, fmt.Sprintf(`%s fmt %s`, runGolangCILint, strings.Join(golangciTargets, " ")))
 				if hasGoMod {
 					cmd.Exec(a, "go mod tidy")
@@ -81,25 +98,68 @@ func DefineTasks(opts ...Option) {
 		}))
 	}
 
-	if !conf.excluded("lint-go") {
-		RegisterCommandDownloads(runGolangCILint, runReviewDog+" -version")
+	if conf.shouldDefine("lint-go") {
+		explainGoDetection("lint-go", conf, hasGoMod, hasBuildGoMod)
+		registerToolDownloads(ToolGolangCILint, ToolReviewdog)
 		RegisterLintTask(goyek.Define(goyek.Task{
 			Name:     "lint-go",
 			Usage:    "Lints Go code.",
 			Parallel: true,
 			Action: func(a *goyek.A) {
+				if conf.allModules {
+					forEachModule(a, conf, selectedModDirs(a), false, func(a *goyek.A, dir string) {
+						execReviewdog(conf, a, runReviewDog, "-f=golangci-lint -name=golangci-lint",
+							fmt.Sprintf(`%s run --build-tags "%s" --timeout=20m ./...`,
+								runGolangCILint, strings.Join(conf.buildTags, ",")), cmd.Dir(dir))
+						cmd.Exec(a, "go mod tidy -diff", cmd.Dir(dir))
+						lintGoMod(a, conf, dir)
+					})
+					return
+				}
+				skipIfNotAffected(a, ".", conf.buildFolder)
 				execReviewdog(conf, a, runReviewDog, "-f=golangci-lint -name=golangci-lint",
-					fmt.Sprintf(`go run github.com/golangci/golangci-lint/v2/cmd/golangci-lint@%s run --build-tags "%s" --timeout=20m %s`,
-						conf.verGolangCILint, strings.Join(conf.buildTags, ","), strings.Join(golangciTargets, " ")))
+					fmt.Sprintf(`%s run --build-tags "%s" --timeout=20m %s`,
+						runGolangCILint, strings.Join(conf.buildTags, ","), strings.Join(golangciTargets, " ")))
 				if hasGoMod {
 					cmd.Exec(a, "go mod tidy -diff")
+					lintGoMod(a, conf, ".")
+				}
+				if hasBuildGoMod {
+					lintGoMod(a, conf, conf.buildFolder)
 				}
 			},
 		}))
 	}
 
-	if !conf.excluded("format-json") {
-		RegisterCommandDownloads(runGoPrettier)
+	noLintAPI := "no go.mod"
+	if !conf.lintAPI {
+		noLintAPI = "no LintAPI option"
+	}
+	if conf.shouldDefineDetected("lint-api", conf.lintAPI && hasGoMod, noLintAPI) {
+		registerToolDownloads(ToolApidiff)
+		RegisterLintTask(goyek.Define(goyek.Task{
+			Name:     "lint-api",
+			Usage:    "Checks the exported Go API for incompatible changes since the last release tag.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				if conf.allModules {
+					buildDir, _ := filepath.Abs(conf.buildFolder)
+					forEachModule(a, conf, selectedModDirs(a), false, func(a *goyek.A, dir string) {
+						if dir == buildDir {
+							a.Skip("build module is not released")
+						}
+						lintAPI(a, runApidiff, dir)
+					})
+					return
+				}
+				skipIfNotAffected(a, ".")
+				lintAPI(a, runApidiff, ".")
+			},
+		}))
+	}
+
+	if conf.shouldDefine("format-json") {
+		registerToolDownloads(ToolGoPrettier)
 		RegisterFormatTask(goyek.Define(goyek.Task{
 			Name:     "format-json",
 			Usage:    "Formats JSON code.",
@@ -110,8 +170,8 @@ func DefineTasks(opts ...Option) {
 		}))
 	}
 
-	if !conf.excluded("lint-json") {
-		RegisterCommandDownloads(runGoPrettier)
+	if conf.shouldDefine("lint-json") {
+		registerToolDownloads(ToolGoPrettier)
 		RegisterLintTask(goyek.Define(goyek.Task{
 			Name:     "lint-json",
 			Usage:    "Lints JSON code.",
@@ -122,8 +182,32 @@ func DefineTasks(opts ...Option) {
 		}))
 	}
 
-	if !conf.excluded("format-markdown") {
-		RegisterCommandDownloads(runGoRumdl + " --version")
+	if conf.shouldDefineDetected("format-web", conf.web, "no WebGlobs option") {
+		registerToolDownloads(ToolGoPrettier)
+		RegisterFormatTask(goyek.Define(goyek.Task{
+			Name:     "format-web",
+			Usage:    "Formats frontend code, such as TypeScript, CSS and HTML.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				cmd.Exec(a, runGoPrettier+" --no-error-on-unmatched-pattern --write "+prettierWebArgs(conf))
+			},
+		}))
+	}
+
+	if conf.shouldDefineDetected("lint-web", conf.web, "no WebGlobs option") {
+		registerToolDownloads(ToolGoPrettier)
+		RegisterLintTask(goyek.Define(goyek.Task{
+			Name:     "lint-web",
+			Usage:    "Lints frontend code, such as TypeScript, CSS and HTML.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				cmd.Exec(a, runGoPrettier+" --no-error-on-unmatched-pattern --check "+prettierWebArgs(conf))
+			},
+		}))
+	}
+
+	if conf.shouldDefine("format-markdown") {
+		registerToolDownloads(ToolGoRumdl)
 		RegisterFormatTask(goyek.Define(goyek.Task{
 			Name:     "format-markdown",
 			Usage:    "Formats Markdown code.",
@@ -134,8 +218,8 @@ func DefineTasks(opts ...Option) {
 		}))
 	}
 
-	if !conf.excluded("lint-markdown") {
-		RegisterCommandDownloads(runGoRumdl + " --version")
+	if conf.shouldDefine("lint-markdown") {
+		registerToolDownloads(ToolGoRumdl)
 		RegisterLintTask(goyek.Define(goyek.Task{
 			Name:     "lint-markdown",
 			Usage:    "Lints Markdown code.",
@@ -146,8 +230,20 @@ func DefineTasks(opts ...Option) {
 		}))
 	}
 
-	if !conf.excluded("format-shell") {
-		RegisterCommandDownloads(runGoPrettier)
+	if conf.shouldDefine("lint-links") {
+		registerToolDownloads(ToolReviewdog)
+		RegisterLintTask(goyek.Define(goyek.Task{
+			Name:     "lint-links",
+			Usage:    "Lints links and anchors in Markdown files.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				lintLinks(a, conf, root, runReviewDog)
+			},
+		}))
+	}
+
+	if conf.shouldDefine("format-shell") {
+		registerToolDownloads(ToolGoPrettier)
 		RegisterFormatTask(goyek.Define(goyek.Task{
 			Name:     "format-shell",
 			Usage:    "Formats shell-like code, including Dockerfile, ignore, dotenv.",
@@ -158,20 +254,57 @@ func DefineTasks(opts ...Option) {
 		}))
 	}
 
-	if !conf.excluded("lint-shell") {
-		RegisterCommandDownloads(runGoPrettier)
+	if conf.shouldDefine("lint-shell") {
+		registerToolDownloads(ToolGoPrettier, ToolGoShellcheck, ToolReviewdog)
 		RegisterLintTask(goyek.Define(goyek.Task{
 			Name:     "lint-shell",
 			Usage:    "Lints shell-like code, including Dockerfile, ignore, dotenv.",
 			Parallel: true,
 			Action: func(a *goyek.A) {
 				cmd.Exec(a, runGoPrettier+" --no-error-on-unmatched-pattern --check '**/*.sh' '**/*.bash' '**/Dockerfile' '**/*.dockerfile' '**/.*ignore' '**/.env*'")
+				lintShellScripts(a, conf, runGoShellcheck, runReviewDog)
+			},
+		}))
+	}
+
+	if conf.shouldDefine("lint-docker") {
+		registerToolDownloads(ToolReviewdog)
+		RegisterLintTask(goyek.Define(goyek.Task{
+			Name:     "lint-docker",
+			Usage:    "Lints Dockerfiles for common problems.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				lintDocker(a, conf, runReviewDog)
+			},
+		}))
+	}
+
+	if conf.shouldDefine("format-spelling") {
+		registerToolDownloads(ToolMisspell)
+		RegisterFormatTask(goyek.Define(goyek.Task{
+			Name:     "format-spelling",
+			Usage:    "Corrects misspellings in Markdown and Go comments.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				execMisspell(a, conf, runMisspell, runReviewDog, true)
+			},
+		}))
+	}
+
+	if conf.shouldDefine("lint-spelling") {
+		registerToolDownloads(ToolMisspell, ToolReviewdog)
+		RegisterLintTask(goyek.Define(goyek.Task{
+			Name:     "lint-spelling",
+			Usage:    "Lints spelling in Markdown and Go comments and strings.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				execMisspell(a, conf, runMisspell, runReviewDog, false)
 			},
 		}))
 	}
 
-	if !conf.excluded("format-toml") {
-		RegisterCommandDownloads(runGoTombi + " --version")
+	if conf.shouldDefine("format-toml") {
+		registerToolDownloads(ToolGoTombi)
 		RegisterFormatTask(goyek.Define(goyek.Task{
 			Name:     "format-toml",
 			Usage:    "Formats TOML code.",
@@ -186,8 +319,8 @@ func DefineTasks(opts ...Option) {
 		}))
 	}
 
-	if !conf.excluded("lint-toml") {
-		RegisterCommandDownloads(runGoTombi + " --version")
+	if conf.shouldDefine("lint-toml") {
+		registerToolDownloads(ToolGoTombi)
 		RegisterLintTask(goyek.Define(goyek.Task{
 			Name:     "lint-toml",
 			Usage:    "Lints TOML code.",
@@ -204,8 +337,56 @@ func DefineTasks(opts ...Option) {
 		}))
 	}
 
-	if !conf.excluded("format-yaml") {
-		RegisterCommandDownloads(runGoPrettier, runGoRyl+" --version")
+	if conf.shouldDefine("format-hcl") {
+		registerToolDownloads(ToolHCLFmt)
+		RegisterFormatTask(goyek.Define(goyek.Task{
+			Name:     "format-hcl",
+			Usage:    "Formats HCL code, including Terraform.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				execHCLFmt(a, conf, runHCLFmt, root, target, "-w")
+			},
+		}))
+	}
+
+	if conf.shouldDefine("lint-hcl") {
+		registerToolDownloads(ToolHCLFmt)
+		RegisterLintTask(goyek.Define(goyek.Task{
+			Name:     "lint-hcl",
+			Usage:    "Lints HCL code, including Terraform.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				execHCLFmt(a, conf, runHCLFmt, root, target, "-check -require-no-change")
+			},
+		}))
+	}
+
+	if conf.shouldDefine("format-sql") {
+		registerToolDownloads(ToolGoSQLFormatter)
+		RegisterFormatTask(goyek.Define(goyek.Task{
+			Name:     "format-sql",
+			Usage:    "Formats SQL code.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				formatSQL(a, conf, false)
+			},
+		}))
+	}
+
+	if conf.shouldDefine("lint-sql") {
+		registerToolDownloads(ToolGoSQLFormatter, ToolReviewdog)
+		RegisterLintTask(goyek.Define(goyek.Task{
+			Name:     "lint-sql",
+			Usage:    "Lints SQL code, including unsafe patterns in migrations.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				lintSQL(a, conf, runReviewDog)
+			},
+		}))
+	}
+
+	if conf.shouldDefine("format-yaml") {
+		registerToolDownloads(ToolGoPrettier, ToolGoRyl)
 		RegisterFormatTask(goyek.Define(goyek.Task{
 			Name:     "format-yaml",
 			Usage:    "Formats YAML code.",
@@ -222,8 +403,8 @@ func DefineTasks(opts ...Option) {
 		}))
 	}
 
-	if !conf.excluded("lint-yaml") {
-		RegisterCommandDownloads(runGoPrettier, runGoRyl+" --version")
+	if conf.shouldDefine("lint-yaml") {
+		registerToolDownloads(ToolGoPrettier, ToolGoRyl)
 		RegisterLintTask(goyek.Define(goyek.Task{
 			Name:     "lint-yaml",
 			Usage:    "Lints YAML code.",
@@ -240,12 +421,20 @@ func DefineTasks(opts ...Option) {
 		}))
 	}
 
-	if !conf.excluded("test-go") {
+	if conf.shouldDefine("test-go") {
 		RegisterTestTask(goyek.Define(goyek.Task{
 			Name:  "test-go",
 			Usage: "Runs Go unit tests.",
 			Action: func(a *goyek.A) {
-				if err := os.MkdirAll(conf.artifactsPath, 0o755); err != nil { //nolint:gosec // common for build artifacts
+				if !conf.allModules {
+					skipIfNotAffected(a, ".")
+				}
+				artifactsPath, err := filepath.Abs(conf.artifactsPath)
+				if err != nil {
+					a.Errorf("failed to resolve out directory: %v", err)
+					return
+				}
+				if err := os.MkdirAll(artifactsPath, 0o755); err != nil { //nolint:gosec // common for build artifacts
 					a.Errorf("failed to create out directory: %v", err)
 					return
 				}
@@ -253,17 +442,30 @@ func DefineTasks(opts ...Option) {
 				if conf.goTestsumFormat != "" {
 					format = "--format=" + conf.goTestsumFormat
 				}
-				coverage := ""
-				if !conf.disableCoverage {
-					coverage = fmt.Sprintf("-coverprofile=%s -covermode=atomic", filepath.Join(conf.artifactsPath, "coverage.txt"))
+				goTest := func(a *goyek.A, dir string, coverageFile string) {
+					ldflags := ""
+					if conf.buildVersionVariable != "" {
+						ldflags = fmt.Sprintf(`-ldflags="-X %s=%s"`, conf.buildVersionVariable, buildVersion(a, dir))
+					}
+					coverage := ""
+					if !conf.disableCoverage {
+						coverage = fmt.Sprintf("-coverprofile=%s -covermode=atomic", filepath.Join(artifactsPath, coverageFile))
+					}
+					cmd.Exec(a, fmt.Sprintf("%s %s -- %s %s -v -timeout=20m ./...", runGoTestsum, format, ldflags, coverage), cmd.Dir(dir))
+				}
+				if conf.allModules {
+					forEachModule(a, conf, selectedModDirs(a), false, func(a *goyek.A, dir string) {
+						goTest(a, dir, moduleCoverageFile(dir))
+					})
+					return
 				}
-				cmd.Exec(a, fmt.Sprintf("%s %s -- %s -v -timeout=20m ./...", runGoTestsum, format, coverage))
+				goTest(a, ".", "coverage.txt")
 			},
 		}))
 	}
 
-	if !conf.excluded("lint-github") && fileExists(".github") {
-		RegisterCommandDownloads(runPinact, runActionlint, runGoShellcheck+" --version")
+	if conf.shouldDefineDetected("lint-github", fileExists(".github"), "no .github folder") {
+		registerToolDownloads(ToolPinact, ToolActionlint, ToolGoShellcheck)
 		RegisterLintTask(goyek.Define(goyek.Task{
 			Name:     "lint-github",
 			Usage:    "Lints GitHub Actions workflows.",
@@ -275,6 +477,91 @@ func DefineTasks(opts ...Option) {
 		}))
 	}
 
+	var sbomDeps goyek.Deps
+	var buildGoTask *goyek.DefinedTask
+	if conf.shouldDefineDetected("build-go", len(conf.buildPackages) > 0, "no BuildPackages option") {
+		buildGoTask = goyek.Define(goyek.Task{
+			Name:  "build-go",
+			Usage: "Compiles main packages for each build target into the artifacts path.",
+			Action: func(a *goyek.A) {
+				buildGo(a, conf)
+			},
+		})
+		sbomDeps = append(sbomDeps, buildGoTask)
+	}
+
+	// Tasks packaging build-go outputs are only defined along with it.
+	noBuildGo := "no BuildPackages option"
+	if len(conf.buildPackages) > 0 {
+		noBuildGo = "build-go is excluded"
+	}
+
+	if conf.shouldDefineDetected("build-image", buildGoTask != nil, noBuildGo) {
+		goyek.Define(goyek.Task{
+			Name:  "build-image",
+			Usage: "Assembles an OCI image layout tarball from linux build-go outputs into the artifacts path.",
+			Deps:  goyek.Deps{buildGoTask},
+			Action: func(a *goyek.A) {
+				buildImage(a, conf)
+			},
+		})
+	}
+
+	if conf.shouldDefineDetected("release", buildGoTask != nil, noBuildGo) {
+		goyek.Define(goyek.Task{
+			Name:  "release",
+			Usage: "Packages build-go outputs with checksums, changelog and manifest and publishes a GitHub release.",
+			Deps:  goyek.Deps{buildGoTask},
+			Action: func(a *goyek.A) {
+				release(a, conf)
+			},
+		})
+	}
+
+	if conf.shouldDefineDetected("sbom", hasGoMod, "no go.mod") {
+		goyek.Define(goyek.Task{
+			Name:  "sbom",
+			Usage: "Writes CycloneDX and SPDX SBOMs for workspace modules and build-go binaries into the artifacts path.",
+			Deps:  sbomDeps,
+			Action: func(a *goyek.A) {
+				generateSBOMs(a, conf)
+			},
+		})
+	}
+
+	hasGoWork := root != "" && fileExists(filepath.Join(root, "go.work"))
+
+	if conf.shouldDefineDetected("format-workspace", hasGoWork, "no go.work") {
+		RegisterFormatTask(goyek.Define(goyek.Task{
+			Name:  "format-workspace",
+			Usage: "Aligns go directives of workspace modules and syncs their dependencies.",
+			Action: func(a *goyek.A) {
+				formatWorkspace(a, root)
+			},
+		}))
+	}
+
+	if conf.shouldDefineDetected("tag-modules", hasGoWork, "no go.work") {
+		goyek.Define(goyek.Task{
+			Name:  "tag-modules",
+			Usage: "Creates annotated tags with the next version for workspace modules changed since their last tag.",
+			Action: func(a *goyek.A) {
+				tagModules(a, conf)
+			},
+		})
+	}
+
+	if conf.shouldDefineDetected("lint-workspace", hasGoWork, "no go.work") {
+		RegisterLintTask(goyek.Define(goyek.Task{
+			Name:     "lint-workspace",
+			Usage:    "Lints consistency of go.mod files across workspace modules.",
+			Parallel: true,
+			Action: func(a *goyek.A) {
+				lintWorkspace(a, root)
+			},
+		}))
+	}
+
 	goyek.Define(goyek.Task{
 		Name:  "download-tools",
 		Usage: "Downloads tool dependencies for this module.",
@@ -283,40 +570,79 @@ func DefineTasks(opts ...Option) {
 				for c := range commandDownloads {
 					cmd.Exec(a, c, cmd.Stdout(io.Discard))
 				}
+				for t := range toolDownloads {
+					cmd.Exec(a, toolDownloadCommand(t), cmd.Stdout(io.Discard))
+				}
 			}
 			// Ignore downloadTools for gotestsum
 			if !conf.excluded("test-go") {
-				cmd.Exec(a, runGoTestsum+" -h", cmd.Stdout(io.Discard))
+				cmd.Exec(a, toolDownloadCommand(ToolGoTestsum), cmd.Stdout(io.Discard))
 			}
 		},
 	})
 
+	goyek.Define(goyek.Task{
+		Name:  "outdated-tools",
+		Usage: "Reports tools with newer versions available, -write-tool-versions updates the tool versions file.",
+		Action: func(a *goyek.A) {
+			outdatedTools(a, conf)
+		},
+	})
+
+	goyek.Define(goyek.Task{
+		Name:  "tasks",
+		Usage: "Lists default tasks and whether they are enabled, excluded by option, or disabled by detection.",
+		Action: func(a *goyek.A) {
+			printTaskDecisions(a)
+		},
+	})
+
+	goyek.Define(goyek.Task{
+		Name:  "doctor",
+		Usage: "Diagnoses the build environment and prints fixes for problems.",
+		Action: func(a *goyek.A) {
+			runDoctor(a, conf)
+		},
+	})
+
 	goyek.Define(goyek.Task{
 		Name:  "download",
 		Usage: "Downloads build dependencies for entire workspace.",
 		Action: func(a *goyek.A) {
-			for _, dir := range modDirs(a) {
-				cmd.Exec(a, "go mod download", cmd.Dir(dir))
+			forEachModule(a, conf, modDirs(a), false, func(a *goyek.A, dir string) {
+				if !cmd.Exec(a, "go mod download", cmd.Dir(dir)) {
+					return
+				}
 				if !strings.HasSuffix(dir, string(filepath.Separator)+conf.buildFolder) {
 					cmd.Exec(a, fmt.Sprintf("go run ./%s download-tools", conf.buildFolder), cmd.Dir(dir))
 				}
-			}
+			})
 		},
 	})
 
-	if !conf.excluded("runall") {
+	if conf.shouldDefine("runall") {
 		goyek.Define(goyek.Task{
 			Name:  "runall",
-			Usage: "Runs a command in each module in the workspace.",
+			Usage: "Runs a command in each module in the workspace, which may use {{.Dir}}, {{.Path}} and {{.GoVersion}}.",
 			Action: func(a *goyek.A) {
-				command := strings.Join(flag.CommandLine.Args(), " ")
-				for _, dir := range modDirs(a) {
-					cmd.Exec(a, command, cmd.Dir(dir))
-				}
+				runAll(a, conf, strings.Join(flag.CommandLine.Args(), " "))
 			},
 		})
 	}
 
+	goyek.Define(goyek.Task{
+		Name:  "affected",
+		Usage: "Lists workspace modules affected by changes since the git ref passed with -affected.",
+		Action: func(a *goyek.A) {
+			if *affectedBase == "" {
+				a.Fatal("-affected must be set to the base git ref to compare against")
+			}
+			for _, m := range affectedModules(a, *affectedBase) {
+				_, _ = fmt.Fprintln(a.Output(), moduleLabel(m.Dir))
+			}
+		},
+	})
+
 	goyek.Define(goyek.Task{
 		Name:  "format",
 		Usage: "Format code in various languages.",
@@ -357,16 +683,29 @@ type config struct {
 	goTestsumFormat  string
 	disableCoverage  bool
 
-	verActionlint   string
-	verGolangCILint string
-	verGoPrettier   string
-	verGoRyl        string
-	verGoRumdl      string
-	verGoShellcheck string
-	verGoTombi      string
-	verGoTestsum    string
-	verPinact       string
-	verReviewdog    string
+	toolVersions     map[string]string
+	toolVersionsFile string
+
+	goModToolchain string
+	bannedModules  []string
+	lintAPI        bool
+
+	buildPackages        []string
+	buildTargets         []string
+	buildVersionVariable string
+	imageBaseLayer       string
+	imageName            string
+
+	shellcheckRC     string
+	sqlMigrationDirs []string
+
+	web      bool
+	webGlobs []string
+
+	spellingWordList string
+
+	allModules        bool
+	moduleConcurrency int
 
 	downloadToolsAllOSes bool
 }
@@ -419,8 +758,29 @@ func (e excludeTasks) apply(c *config) {
 	c.excludeTasks = append(c.excludeTasks, e.tasks...)
 }
 
+func explainGoDetection(task string, conf config, hasGoMod bool, hasBuildGoMod bool) {
+	if conf.allModules {
+		explainDetection(task, "runs in each workspace module with the AllModules option")
+		return
+	}
+	switch {
+	case !hasGoMod && !hasBuildGoMod:
+		explainDetection(task, fmt.Sprintf("no go.mod in root or build folder %s, no Go code checked", conf.buildFolder))
+	case !hasGoMod:
+		explainDetection(task, fmt.Sprintf("no go.mod, only build folder %s checked and go mod tidy skipped", conf.buildFolder))
+	case !hasBuildGoMod:
+		explainDetection(task, fmt.Sprintf("no go.mod in build folder %s, checked as part of the root module", conf.buildFolder))
+	}
+}
+
 func pathRelativeToRoot() (string, string) {
-	dir, err := filepath.Abs(".")
+	return dirRelativeToRoot(".")
+}
+
+// dirRelativeToRoot returns the root of the repository containing dir, identified by a .git
+// or go.work, and the path of dir relative to it.
+func dirRelativeToRoot(path string) (string, string) {
+	dir, err := filepath.Abs(path)
 	if err != nil {
 		return "", ""
 	}
@@ -432,7 +792,7 @@ func pathRelativeToRoot() (string, string) {
 		}
 
 		parent := filepath.Dir(base)
-		if parent == dir || parent == "" {
+		if parent == base || parent == "" {
 			break
 		}
 
@@ -457,6 +817,32 @@ func fileExists(p string) bool {
 	return false
 }
 
+// findFiles returns the files under the current directory that match, skipping hidden
+// folders, dependencies and the artifacts path.
+func findFiles(conf config, match func(path string) bool) ([]string, error) {
+	artifacts := filepath.Clean(conf.artifactsPath)
+	var res []string
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "vendor" || path == artifacts) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && match(path) {
+			res = append(res, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build: finding files: %w", err)
+	}
+	return res, nil
+}
+
 func modDirs(a *goyek.A) []string {
 	var out bytes.Buffer
 	if !cmd.Exec(a, "go list -m -f {{.Dir}}", cmd.Stdout(&out)) {
@@ -496,10 +882,34 @@ func execReviewdog(conf config, a *goyek.A, runReviewdog string, format string,
 		return cmd.Exec(a, cmdLine, opts...)
 	}
 	var stderr bytes.Buffer
-	if cmd.Exec(a, cmdLine, append(opts, cmd.Stderr(&stderr))...) {
+	if cmd.Exec(a, cmdLine, slices.Concat(opts, []cmd.Option{cmd.Stderr(&stderr)})...) {
 		return true
 	}
-	return cmd.Exec(a, fmt.Sprintf("%s %s -fail-level=warning -reporter=github-check", runReviewdog, format), cmd.Stdin(&stderr))
+	// Run reviewdog with the same options, notably the directory, so it resolves reported paths.
+	return cmd.Exec(a, fmt.Sprintf("%s %s -fail-level=warning -reporter=github-check", runReviewdog, format),
+		slices.Concat(opts, []cmd.Option{cmd.Stdin(&stderr)})...)
+}
+
+// lintDiagnostic is a problem found by a linter implemented in go-build.
+type lintDiagnostic struct {
+	Line    int
+	Code    string
+	Message string
+}
+
+// reportReviewdog fails the task with a report of diagnostics produced by go-build itself,
+// passing it to reviewdog with format in CI the same as execReviewdog.
+func reportReviewdog(conf config, a *goyek.A, runReviewdog string, format string, report string, opts ...cmd.Option) {
+	a.Helper()
+	if conf.disableReviewdog || os.Getenv("CI") != "true" {
+		a.Error(strings.TrimSuffix(report, "\n"))
+		return
+	}
+	if cmd.Exec(a, fmt.Sprintf("%s %s -fail-level=warning -reporter=github-check", runReviewdog, format),
+		slices.Concat(opts, []cmd.Option{cmd.Stdin(strings.NewReader(report))})...) {
+		// reviewdog may filter diagnostics outside of the diff, but the task should still fail.
+		a.Error(strings.TrimSuffix(report, "\n"))
+	}
 }
 
 // GoTestsumFormat returns an Option to customize the format reported by test results via gotestsum.
@@ -516,122 +926,82 @@ func (g goTestsumFormat) apply(c *config) {
 
 // VersionActionlint returns an Option to set the version of actionlint to use. If unset,
 // a default version is used which may not be the latest.
+//
+// Deprecated: use ToolVersion(ToolActionlint, version).
 func VersionActionlint(version string) Option {
-	return versionActionlint(version)
-}
-
-type versionActionlint string
-
-func (v versionActionlint) apply(c *config) {
-	c.verActionlint = string(v)
+	return ToolVersion(ToolActionlint, version)
 }
 
 // VersionGolangCILint returns an Option to set the version of golangci-lint to use. If unset,
 // a default version is used which may not be the latest.
+//
+// Deprecated: use ToolVersion(ToolGolangCILint, version).
 func VersionGolangCILint(version string) Option {
-	return versionGolangCILint(version)
-}
-
-type versionGolangCILint string
-
-func (v versionGolangCILint) apply(c *config) {
-	c.verGolangCILint = string(v)
+	return ToolVersion(ToolGolangCILint, version)
 }
 
 // VersionGoPrettier returns an Option to set the version of go-prettier to use. If unset,
 // a default version is used which may not be the latest.
+//
+// Deprecated: use ToolVersion(ToolGoPrettier, version).
 func VersionGoPrettier(version string) Option {
-	return versionGoPrettier(version)
-}
-
-type versionGoPrettier string
-
-func (v versionGoPrettier) apply(c *config) {
-	c.verGoPrettier = string(v)
+	return ToolVersion(ToolGoPrettier, version)
 }
 
 // VersionGoRumdl returns an Option to set the version of go-rumdl to use. If unset,
 // a default version is used which may not be the latest.
+//
+// Deprecated: use ToolVersion(ToolGoRumdl, version).
 func VersionGoRumdl(version string) Option {
-	return versionGoRumdl(version)
-}
-
-type versionGoRumdl string
-
-func (v versionGoRumdl) apply(c *config) {
-	c.verGoRumdl = string(v)
+	return ToolVersion(ToolGoRumdl, version)
 }
 
 // VersionGoShellcheck returns an Option to set the version of go-shellcheck to use. If unset,
 // a default version is used which may not be the latest.
+//
+// Deprecated: use ToolVersion(ToolGoShellcheck, version).
 func VersionGoShellcheck(version string) Option {
-	return versionGoShellcheck(version)
-}
-
-type versionGoShellcheck string
-
-func (v versionGoShellcheck) apply(c *config) {
-	c.verGoShellcheck = string(v)
+	return ToolVersion(ToolGoShellcheck, version)
 }
 
 // VersionGoTestsum returns an Option to set the version of gotestsum to use. If unset,
 // a default version is used which may not be the latest.
+//
+// Deprecated: use ToolVersion(ToolGoTestsum, version).
 func VersionGoTestsum(version string) Option {
-	return versionGoTestsum(version)
-}
-
-type versionGoTestsum string
-
-func (v versionGoTestsum) apply(c *config) {
-	c.verGoTestsum = string(v)
+	return ToolVersion(ToolGoTestsum, version)
 }
 
 // VersionGoRyl returns an Option to set the version of go-ryl to use. If unset,
 // a default version is used which may not be the latest.
+//
+// Deprecated: use ToolVersion(ToolGoRyl, version).
 func VersionGoRyl(version string) Option {
-	return versionGoRyl(version)
-}
-
-type versionGoRyl string
-
-func (v versionGoRyl) apply(c *config) {
-	c.verGoRyl = string(v)
+	return ToolVersion(ToolGoRyl, version)
 }
 
 // VersionGoTombi returns an Option to set the version of go-tombi to use. If unset,
 // a default version is used which may not be the latest.
+//
+// Deprecated: use ToolVersion(ToolGoTombi, version).
 func VersionGoTombi(version string) Option {
-	return versionGoTombi(version)
-}
-
-type versionGoTombi string
-
-func (v versionGoTombi) apply(c *config) {
-	c.verGoTombi = string(v)
+	return ToolVersion(ToolGoTombi, version)
 }
 
 // VersionPinact returns an Option to set the version of pinact to use. If unset,
 // a default version is used which may not be the latest.
+//
+// Deprecated: use ToolVersion(ToolPinact, version).
 func VersionPinact(version string) Option {
-	return versionPinact(version)
-}
-
-type versionPinact string
-
-func (v versionPinact) apply(c *config) {
-	c.verPinact = string(v)
+	return ToolVersion(ToolPinact, version)
 }
 
 // VersionReviewdog returns an Option to set the version of reviewdog to use. If unset,
 // a default version is used which may not be the latest.
+//
+// Deprecated: use ToolVersion(ToolReviewdog, version).
 func VersionReviewdog(version string) Option {
-	return versionReviewdog(version)
-}
-
-type versionReviewdog string
-
-func (v versionReviewdog) apply(c *config) {
-	c.verReviewdog = string(v)
+	return ToolVersion(ToolReviewdog, version)
 }
 
 // DownloadToolsAllOSes returns an Option to download tools for all operating systems.
@@ -647,6 +1017,19 @@ func (d downloadToolsAllOSes) apply(c *config) {
 	c.downloadToolsAllOSes = true
 }
 
+// AllModules returns an Option to run the Go tasks format-go, lint-go and test-go in every
+// module of the workspace instead of only the current one, with output grouped per module.
+// This allows running checks for an entire go.work repository from its root.
+func AllModules() Option {
+	return allModules{}
+}
+
+type allModules struct{}
+
+func (a allModules) apply(c *config) {
+	c.allModules = true
+}
+
 // DisableCoverage returns an Option to disable coverage reporting in Go tests. By default, coverage is enabled.
 func DisableCoverage() Option {
 	return disableCoverage{}
@@ -0,0 +1,208 @@
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// moduleRelease is a proposed release of a workspace module.
+type moduleRelease struct {
+	module  parsedModule
+	prefix  string
+	current string
+	next    string
+}
+
+func (r moduleRelease) tag() string {
+	return r.prefix + r.next
+}
+
+// tagModules tags workspace modules that changed since their last tag with the next version
+// based on conventional commits.
+func tagModules(a *goyek.A, conf config) {
+	a.Helper()
+	ctx := a.Context()
+
+	if status, err := git(ctx, ".", "status", "--porcelain"); err != nil {
+		a.Error(err)
+		return
+	} else if status != "" {
+		a.Error("working tree has uncommitted changes, commit them before tagging")
+		return
+	}
+
+	buildDir, _ := filepath.Abs(conf.buildFolder)
+	mods := parseWorkspaceModules(a)
+	var modDirs []string
+	for _, m := range mods {
+		modDirs = append(modDirs, m.Dir)
+	}
+	var releases []moduleRelease
+	for _, m := range mods {
+		if m.Dir == buildDir {
+			continue
+		}
+		root, prefix := moduleTagPrefix(m.Dir)
+		if root == "" {
+			a.Errorf("no repository root found for %s", m.Dir)
+			continue
+		}
+		v, err := gitVersion(ctx, m.Dir, prefix)
+		if err != nil {
+			a.Error(err)
+			continue
+		}
+
+		// Limit history to the module directory, excluding nested modules.
+		args := []string{"log", "--format=%s%x1f%b%x1e"}
+		if v.Tag != "" {
+			args = append(args, v.Tag+"..HEAD")
+		}
+		args = append(args, moduleLogPaths(m.Dir, modDirs)...)
+		log, err := git(ctx, m.Dir, args...)
+		if err != nil {
+			a.Error(err)
+			continue
+		}
+		if log == "" {
+			a.Logf("%s: unchanged since %s", m.Path, v.Tag)
+			continue
+		}
+
+		current := ""
+		if v.Tag != "" {
+			current = v.Semver
+		}
+		_, pathMajor, _ := module.SplitPathVersion(m.Path)
+		next := nextModuleVersion(current, pathMajor, log)
+		if module.CheckPathMajor(next, pathMajor) != nil {
+			a.Errorf("%s: breaking changes since %s require a new major version %s, which needs the module path to end in /%s",
+				m.Path, v.Tag, next, semver.Major(next))
+			continue
+		}
+		releases = append(releases, moduleRelease{module: m, prefix: prefix, current: current, next: next})
+	}
+	if a.Failed() {
+		return
+	}
+
+	released := func(path string, version string) bool {
+		if slices.ContainsFunc(releases, func(r moduleRelease) bool { return r.module.Path == path && r.next == version }) {
+			return true
+		}
+		for _, m := range mods {
+			if m.Path != path {
+				continue
+			}
+			root, prefix := moduleTagPrefix(m.Dir)
+			_, err := git(ctx, root, "rev-parse", "--verify", "--quiet", "refs/tags/"+prefix+version)
+			return err == nil
+		}
+		return true
+	}
+	for _, m := range mods {
+		// The build module is never released so may require anything.
+		if m.Dir == buildDir {
+			continue
+		}
+		for _, r := range m.file.Require {
+			// A require replaced with a local directory does not use the required version.
+			if slices.ContainsFunc(m.file.Replace, func(rep *modfile.Replace) bool {
+				return rep.Old.Path == r.Mod.Path && modfile.IsDirectoryPath(rep.New.Path)
+			}) {
+				continue
+			}
+			if slices.ContainsFunc(mods, func(o parsedModule) bool { return o.Path == r.Mod.Path }) && !released(r.Mod.Path, r.Mod.Version) {
+				a.Errorf("%s:%d: requires %s %s which is not released, require a released version before tagging",
+					m.GoMod, r.Syntax.Start.Line, r.Mod.Path, r.Mod.Version)
+			}
+		}
+	}
+	if a.Failed() {
+		return
+	}
+
+	for _, r := range releases {
+		from := r.current
+		if from == "" {
+			from = "untagged"
+		}
+		a.Logf("%s: %s -> %s", r.module.Path, from, r.next)
+		if _, err := git(ctx, r.module.Dir, "tag", "-a", r.tag(), "-m", r.tag()); err != nil {
+			a.Error(err)
+			return
+		}
+	}
+	if len(releases) > 0 {
+		a.Log("created tags locally, push them with `git push --tags`")
+	}
+}
+
+// moduleTagPrefix returns the root of the repository containing the module in dir and the
+// prefix of its tags, its path relative to the root followed by a slash, or empty for a
+// module at the root.
+func moduleTagPrefix(dir string) (string, string) {
+	root, target := dirRelativeToRoot(dir)
+	if root == "" || target == "." {
+		return root, ""
+	}
+	return root, filepath.ToSlash(target) + "/"
+}
+
+// moduleLogPaths returns the git pathspec limiting history to the module in dir, excluding
+// the modules in modDirs nested in it.
+func moduleLogPaths(dir string, modDirs []string) []string {
+	paths := []string{"--", "."}
+	for _, other := range modDirs {
+		if rel, err := filepath.Rel(dir, other); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			paths = append(paths, ":(exclude)"+filepath.ToSlash(rel))
+		}
+	}
+	return paths
+}
+
+// nextModuleVersion returns the next version after current given the git log of
+// conventional commits since it. Breaking changes bump the major version, or the minor
+// version before v1, features bump the minor version, and anything else the patch version.
+// A module whose path has a major version suffix such as /v2 newer than current starts at
+// that major version, and any other module with no current version at v0.1.0.
+func nextModuleVersion(current string, pathMajor string, log string) string {
+	if major := module.PathMajorPrefix(pathMajor); major != "" && (current == "" || semver.Compare(semver.Major(current), major) < 0) {
+		return major + ".0.0"
+	}
+	if current == "" {
+		return "v0.1.0"
+	}
+	breaking, feature := false, false
+	for entry := range strings.SplitSeq(log, "\x1e") {
+		subject, body, _ := strings.Cut(strings.TrimSpace(entry), "\x1f")
+		m := conventionalCommit.FindStringSubmatch(subject)
+		if m == nil {
+			continue
+		}
+		if m[3] != "" || strings.Contains(body, "BREAKING CHANGE") {
+			breaking = true
+		}
+		if m[1] == "feat" {
+			feature = true
+		}
+	}
+
+	var major, minor, patch int
+	_, _ = fmt.Sscanf(semver.Canonical(current), "v%d.%d.%d", &major, &minor, &patch)
+	switch {
+	case breaking && major > 0:
+		return fmt.Sprintf("v%d.0.0", major+1)
+	case breaking || feature:
+		return fmt.Sprintf("v%d.%d.0", major, minor+1)
+	default:
+		return fmt.Sprintf("v%d.%d.%d", major, minor, patch+1)
+	}
+}
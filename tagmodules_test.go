@@ -0,0 +1,85 @@
+package build
+
+import (
+	"bytes"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/goyek/goyek/v3"
+)
+
+func TestNextModuleVersion(t *testing.T) {
+	commits := func(subjects ...string) string {
+		var log strings.Builder
+		for _, s := range subjects {
+			subject, body, _ := strings.Cut(s, "\n")
+			log.WriteString(subject + "\x1f" + body + "\x1e\n")
+		}
+		return log.String()
+	}
+	tests := []struct {
+		name      string
+		current   string
+		pathMajor string
+		log       string
+		want      string
+	}{
+		{name: "untagged", log: commits("fix: a"), want: "v0.1.0"},
+		{name: "untagged v2 path", pathMajor: "/v2", log: commits("fix: a"), want: "v2.0.0"},
+		{name: "untagged gopkg.in path", pathMajor: ".v3", log: commits("fix: a"), want: "v3.0.0"},
+		{name: "fix", current: "v1.2.3", log: commits("fix: a", "docs: b"), want: "v1.2.4"},
+		{name: "not conventional", current: "v1.2.3", log: commits("Update README"), want: "v1.2.4"},
+		{name: "feature", current: "v1.2.3", log: commits("fix: a", "feat(api): b"), want: "v1.3.0"},
+		{name: "breaking", current: "v1.2.3", log: commits("feat!: a"), want: "v2.0.0"},
+		{name: "breaking in body", current: "v1.2.3", log: commits("fix: a\nBREAKING CHANGE: removed b"), want: "v2.0.0"},
+		{name: "breaking before v1", current: "v0.4.1", log: commits("refactor(x)!: a"), want: "v0.5.0"},
+		{name: "feature before v1", current: "v0.4.1", log: commits("feat: a"), want: "v0.5.0"},
+		{name: "v2 path", current: "v2.1.0", pathMajor: "/v2", log: commits("feat: a"), want: "v2.2.0"},
+		{name: "path bumped to v2", current: "v1.5.0", pathMajor: "/v2", log: commits("feat!: a"), want: "v2.0.0"},
+		{name: "v2 path breaking", current: "v2.1.0", pathMajor: "/v2", log: commits("feat!: a"), want: "v3.0.0"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := nextModuleVersion(tc.current, tc.pathMajor, tc.log); got != tc.want {
+				t.Errorf("nextModuleVersion(%q, %q) = %q, want %q", tc.current, tc.pathMajor, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTagModulesLocalReplace(t *testing.T) {
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOWORK", "")
+	t.Setenv("GOPROXY", "off")
+	dir := t.TempDir()
+	initGitRepo(t, dir, map[string]string{
+		"go.work": "go 1.25\n\nuse (\n\t.\n\t./build\n\t./sub\n)\n",
+		"go.mod":  "module example.com/lib\n\ngo 1.25\n",
+		"lib.go":  "package lib\n",
+		// The build module is never released and requires an unreleased version.
+		"build/go.mod":  "module example.com/lib/build\n\ngo 1.25\n\nrequire example.com/lib v0.9.9\n\nreplace example.com/lib => ../\n",
+		"build/main.go": "package main\n\nimport _ \"example.com/lib\"\n\nfunc main() {}\n",
+		// The version required by a local replace is not used.
+		"sub/go.mod": "module example.com/lib/sub\n\ngo 1.25\n\nrequire example.com/lib v0.0.0\n\nreplace example.com/lib => ../\n",
+		"sub/sub.go": "package sub\n\nimport _ \"example.com/lib\"\n",
+	})
+	t.Chdir(dir)
+
+	var out bytes.Buffer
+	res := goyek.NewRunner(func(a *goyek.A) {
+		tagModules(a, config{buildFolder: "build"})
+	})(goyek.Input{Output: &out})
+	if res.Status != goyek.StatusPassed {
+		t.Fatalf("status = %v, want passed, output:\n%s", res.Status, out.String())
+	}
+
+	tags, err := exec.Command("git", "tag", "--list").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Fields(string(tags)), []string{"sub/v0.1.0", "v0.1.0"}; !slices.Equal(got, want) {
+		t.Errorf("tags = %v, want %v", got, want)
+	}
+}
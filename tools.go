@@ -0,0 +1,227 @@
+package build
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+	"golang.org/x/mod/module"
+)
+
+// Tool is a Go command-line tool executed with `go run`. Registering a tool with
+// RegisterTool allows its version to be overridden with ToolVersion and ensures it
+// is downloaded by the download task.
+type Tool struct {
+	// Name is the name of the tool, used to refer to it in ToolVersion and ToolCommand.
+	Name string
+
+	// Module is the path of the Go module containing the tool, used to look up
+	// versions of it.
+	Module string
+
+	// Cmd is the package path of the tool's main package. If empty, Module is used.
+	Cmd string
+
+	// Version is the default version of the tool.
+	Version string
+
+	// VersionArgs are arguments to execute the tool with to check that it was
+	// downloaded successfully, for example "--version". The command must exit
+	// successfully when executed with them.
+	VersionArgs []string
+}
+
+func (t *Tool) cmdPath() string {
+	if t.Cmd != "" {
+		return t.Cmd
+	}
+	return t.Module
+}
+
+// Names of tools used by the default tasks, which can be passed to ToolVersion.
+const (
+	ToolActionlint     = "actionlint"
+	ToolApidiff        = "apidiff"
+	ToolGolangCILint   = "golangci-lint"
+	ToolGoPrettier     = "go-prettier"
+	ToolGoRumdl        = "go-rumdl"
+	ToolGoRyl          = "go-ryl"
+	ToolGoShellcheck   = "go-shellcheck"
+	ToolGoSQLFormatter = "go-sql-formatter"
+	ToolGoTestsum      = "gotestsum"
+	ToolGoTombi        = "go-tombi"
+	ToolHCLFmt         = "hclfmt"
+	ToolMisspell       = "misspell"
+	ToolPinact         = "pinact"
+	ToolReviewdog      = "reviewdog"
+)
+
+func defaultTools() []Tool {
+	return []Tool{
+		{
+			Name:    ToolActionlint,
+			Module:  "github.com/rhysd/actionlint",
+			Cmd:     "github.com/rhysd/actionlint/cmd/actionlint",
+			Version: verActionlint,
+		},
+		{
+			Name:        ToolApidiff,
+			Module:      "golang.org/x/exp",
+			Cmd:         "golang.org/x/exp/cmd/apidiff",
+			Version:     verApidiff,
+			VersionArgs: []string{"-h"},
+		},
+		{
+			Name:    ToolGolangCILint,
+			Module:  "github.com/golangci/golangci-lint/v2",
+			Cmd:     "github.com/golangci/golangci-lint/v2/cmd/golangci-lint",
+			Version: verGolangCILint,
+		},
+		{
+			Name:    ToolGoPrettier,
+			Module:  "github.com/wasilibs/go-prettier/v3",
+			Cmd:     "github.com/wasilibs/go-prettier/v3/cmd/prettier",
+			Version: verGoPrettier,
+		},
+		{
+			Name:        ToolGoRumdl,
+			Module:      "github.com/wasilibs/go-rumdl",
+			Cmd:         "github.com/wasilibs/go-rumdl/cmd/rumdl",
+			Version:     verGoRumdl,
+			VersionArgs: []string{"--version"},
+		},
+		{
+			Name:        ToolGoRyl,
+			Module:      "github.com/wasilibs/go-ryl",
+			Cmd:         "github.com/wasilibs/go-ryl/cmd/ryl",
+			Version:     verGoRyl,
+			VersionArgs: []string{"--version"},
+		},
+		{
+			Name:        ToolGoShellcheck,
+			Module:      "github.com/wasilibs/go-shellcheck",
+			Cmd:         "github.com/wasilibs/go-shellcheck/cmd/shellcheck",
+			Version:     verGoShellcheck,
+			VersionArgs: []string{"--version"},
+		},
+		{
+			Name:        ToolGoSQLFormatter,
+			Module:      "github.com/wasilibs/go-sql-formatter/v15",
+			Cmd:         "github.com/wasilibs/go-sql-formatter/v15/cmd/sql-formatter",
+			Version:     verGoSQLFormatter,
+			VersionArgs: []string{"--version"},
+		},
+		{
+			Name:        ToolGoTestsum,
+			Module:      "gotest.tools/gotestsum",
+			Version:     verGoTestsum,
+			VersionArgs: []string{"-h"},
+		},
+		{
+			Name:        ToolGoTombi,
+			Module:      "github.com/wasilibs/go-tombi",
+			Cmd:         "github.com/wasilibs/go-tombi/cmd/tombi",
+			Version:     verGoTombi,
+			VersionArgs: []string{"--version"},
+		},
+		{
+			Name:        ToolHCLFmt,
+			Module:      "github.com/hashicorp/hcl/v2",
+			Cmd:         "github.com/hashicorp/hcl/v2/cmd/hclfmt",
+			Version:     verHCLFmt,
+			VersionArgs: []string{"-version"},
+		},
+		{
+			Name:        ToolMisspell,
+			Module:      "github.com/golangci/misspell",
+			Cmd:         "github.com/golangci/misspell/cmd/misspell",
+			Version:     verMisspell,
+			VersionArgs: []string{"-v"},
+		},
+		{
+			Name:    ToolPinact,
+			Module:  "github.com/suzuki-shunsuke/pinact/v4",
+			Cmd:     "github.com/suzuki-shunsuke/pinact/v4/cmd/pinact",
+			Version: verPinact,
+		},
+		{
+			Name:        ToolReviewdog,
+			Module:      "github.com/reviewdog/reviewdog",
+			Cmd:         "github.com/reviewdog/reviewdog/cmd/reviewdog",
+			Version:     verReviewdog,
+			VersionArgs: []string{"-version"},
+		},
+	}
+}
+
+// ToolCommand returns the `go run` command to execute the registered tool with the given
+// name, using the version set with ToolVersion if any. It panics if no tool with the name
+// has been registered, which would be a bug in the build definition.
+func ToolCommand(name string) string {
+	t, ok := tools[name]
+	if !ok {
+		panic(fmt.Sprintf("build: tool %q is not registered", name))
+	}
+	return "go run " + t.cmdPath() + "@" + toolVersion(t)
+}
+
+// installTool installs the registered tool with the given name into dir, returning the path
+// of its executable. It is used to execute a tool many times without the overhead of
+// `go run` for each execution.
+func installTool(a *goyek.A, name string, dir string) (string, bool) {
+	a.Helper()
+	t, ok := tools[name]
+	if !ok {
+		panic(fmt.Sprintf("build: tool %q is not registered", name))
+	}
+	if !cmd.Exec(a, "go install "+t.cmdPath()+"@"+toolVersion(t), cmd.Env("GOBIN", dir)) {
+		return "", false
+	}
+	exe := path.Base(t.cmdPath())
+	if _, major, ok := module.SplitPathVersion(t.cmdPath()); ok && major != "" {
+		// The executable of a module root with a major version suffix is named after the module.
+		exe = path.Base(strings.TrimSuffix(t.cmdPath(), major))
+	}
+	if runtime.GOOS == "windows" {
+		exe += ".exe"
+	}
+	return filepath.Join(dir, exe), true
+}
+
+func toolVersion(t *Tool) string {
+	if v, ok := toolVersions[t.Name]; ok {
+		return v
+	}
+	return t.Version
+}
+
+func toolDownloadCommand(name string) string {
+	c := ToolCommand(name)
+	if args := tools[name].VersionArgs; len(args) > 0 {
+		c += " " + strings.Join(args, " ")
+	}
+	return c
+}
+
+// ToolVersion returns an Option to set the version of the tool with the given name to use,
+// either one used by the default tasks such as ToolGolangCILint or one registered with
+// RegisterTool. If unset, the default version of the tool is used which may not be the latest.
+func ToolVersion(name string, version string) Option {
+	return toolVersionOption{name: name, version: version}
+}
+
+type toolVersionOption struct {
+	name    string
+	version string
+}
+
+func (t toolVersionOption) apply(c *config) {
+	if c.toolVersions == nil {
+		c.toolVersions = map[string]string{}
+	}
+	c.toolVersions[t.name] = t.version
+}
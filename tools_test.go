@@ -0,0 +1,87 @@
+package build
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/goyek/goyek/v3"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/zip"
+)
+
+func TestInstallTool(t *testing.T) {
+	const modPath, version = "example.com/tool/v2", "v2.0.0"
+	src := t.TempDir()
+	goMod := "module " + modPath + "\n\ngo 1.25\n"
+	writeFiles(t, src, map[string]string{
+		"go.mod":         goMod,
+		"main.go":        "package main\n\nfunc main() { println(\"root\") }\n",
+		"cmd/sub/sub.go": "package main\n\nfunc main() { println(\"sub\") }\n",
+	})
+
+	proxy := t.TempDir()
+	base := filepath.Join(proxy, filepath.FromSlash(modPath), "@v")
+	writeFiles(t, base, map[string]string{
+		"list":            version + "\n",
+		version + ".info": `{"Version":"` + version + `","Time":"2026-01-01T00:00:00Z"}`,
+		version + ".mod":  goMod,
+	})
+	f, err := os.Create(filepath.Join(base, version+".zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := zip.CreateFromDir(f, module.Version{Path: modPath, Version: version}, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "")
+
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		// The executable of a module root is named after the module without the major version.
+		{cmd: "", want: "root"},
+		{cmd: modPath + "/cmd/sub", want: "sub"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.want, func(t *testing.T) {
+			old, hadOld := tools["test-tool"]
+			t.Cleanup(func() {
+				if hadOld {
+					tools["test-tool"] = old
+				} else {
+					delete(tools, "test-tool")
+				}
+			})
+			tools["test-tool"] = &Tool{Name: "test-tool", Module: modPath, Cmd: tc.cmd, Version: version}
+
+			var exe string
+			var out bytes.Buffer
+			res := goyek.NewRunner(func(a *goyek.A) {
+				var ok bool
+				exe, ok = installTool(a, "test-tool", t.TempDir())
+				if !ok {
+					a.FailNow()
+				}
+			})(goyek.Input{Output: &out})
+			if res.Status != goyek.StatusPassed {
+				t.Fatalf("status = %v, want passed, output:\n%s", res.Status, out.String())
+			}
+			got, err := exec.Command(exe).CombinedOutput()
+			if err != nil {
+				t.Fatalf("running %s: %v: %s", exe, err, got)
+			}
+			if string(bytes.TrimSpace(got)) != tc.want {
+				t.Errorf("%s printed %q, want %q", exe, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// Version is the version of a module derived from the state of its git repository.
+type Version struct {
+	// Tag is the nearest tag reachable from HEAD including any module prefix, for example
+	// "sub/v1.2.3". It is empty if there is no tag.
+	Tag string
+
+	// Semver is the semantic version of Tag without the module prefix, for example "v1.2.3",
+	// or "v0.0.0" if there is no tag.
+	Semver string
+
+	// Commits is the number of commits since Tag.
+	Commits int
+
+	// ShortSHA is the abbreviated hash of HEAD.
+	ShortSHA string
+
+	// Dirty is whether the working tree has uncommitted changes.
+	Dirty bool
+}
+
+// String returns the version as a semantic version. A clean checkout of a tag returns
+// the tag's version, for example "v1.2.3". Otherwise, it is a prerelease of the next patch
+// version with the number of commits since the tag and the commit, for example
+// "v1.2.4-dev.5+gabcdef1" or "v1.2.4-dev.5+gabcdef1.dirty".
+func (v Version) String() string {
+	if v.Tag != "" && v.Commits == 0 && !v.Dirty {
+		return v.Semver
+	}
+	res := nextPatch(v.Semver) + "-dev." + strconv.Itoa(v.Commits) + "+g" + v.ShortSHA
+	if v.Dirty {
+		res += ".dirty"
+	}
+	return res
+}
+
+func nextPatch(v string) string {
+	if v == "v0.0.0" {
+		return v
+	}
+	parts := strings.SplitN(strings.TrimPrefix(semver.Canonical(v), "v"), ".", 3)
+	patch, _ := strconv.Atoi(strings.SplitN(parts[2], "-", 2)[0])
+	return fmt.Sprintf("v%s.%s.%d", parts[0], parts[1], patch+1)
+}
+
+var describeOutput = regexp.MustCompile(`^(.+)-(\d+)-g([0-9a-f]+)(-dirty)?$`)
+
+// GitVersion returns the version of the module in dir derived from git tags. Tags are
+// expected to follow the Go convention for modules in a multi-module repository, prefixed
+// by the path of the module relative to the repository root, for example "sub/v1.2.3",
+// with no prefix for a module at the root. The repository root is the nearest directory
+// containing .git or go.work, as for other tasks.
+func GitVersion(ctx context.Context, dir string) (Version, error) {
+	root, prefix := moduleTagPrefix(dir)
+	if root == "" {
+		return Version{}, fmt.Errorf("build: no repository root found for %s", dir) //nolint:err113 // dynamic error
+	}
+	return gitVersion(ctx, dir, prefix)
+}
+
+func gitVersion(ctx context.Context, dir string, prefix string) (Version, error) {
+	out, err := git(ctx, dir, "describe", "--tags", "--long", "--dirty", "--abbrev=7", "--match", prefix+"v[0-9]*")
+	if err == nil {
+		if v, ok := parseDescribe(out, prefix); ok {
+			return v, nil
+		}
+	}
+
+	// No matching tag, version from the start of history.
+	sha, err := git(ctx, dir, "rev-parse", "--short=7", "HEAD")
+	if err != nil {
+		return Version{}, err
+	}
+	count, err := git(ctx, dir, "rev-list", "--count", "HEAD")
+	if err != nil {
+		return Version{}, err
+	}
+	commits, _ := strconv.Atoi(count)
+	// Untracked files are ignored, the same as git describe --dirty.
+	status, err := git(ctx, dir, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return Version{}, err
+	}
+	return Version{
+		Semver:   "v0.0.0",
+		Commits:  commits,
+		ShortSHA: sha,
+		Dirty:    status != "",
+	}, nil
+}
+
+// parseDescribe parses the output of `git describe --long --dirty` for a tag with prefix. It
+// returns false if the output does not have a semantic version tag.
+func parseDescribe(out string, prefix string) (Version, bool) {
+	m := describeOutput.FindStringSubmatch(out)
+	if m == nil {
+		return Version{}, false
+	}
+	v := strings.TrimPrefix(m[1], prefix)
+	if !semver.IsValid(v) {
+		return Version{}, false
+	}
+	commits, _ := strconv.Atoi(m[2])
+	return Version{
+		Tag:      m[1],
+		Semver:   v,
+		Commits:  commits,
+		ShortSHA: m[3],
+		Dirty:    m[4] != "",
+	}, true
+}
+
+// commitTime returns the time of the current commit of the repository containing dir.
+func commitTime(ctx context.Context, dir string) (time.Time, error) {
+	out, err := git(ctx, dir, "log", "-1", "--format=%ct")
+	if err != nil {
+		return time.Time{}, err
+	}
+	ct, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("build: parsing commit time %q: %w", out, err)
+	}
+	return time.Unix(ct, 0).UTC(), nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, "git", args...)
+	c.Dir = dir
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("build: git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
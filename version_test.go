@@ -0,0 +1,144 @@
+package build
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestVersionString(t *testing.T) {
+	tests := []struct {
+		name    string
+		version Version
+		want    string
+	}{
+		{name: "exact tag", version: Version{Tag: "v1.2.3", Semver: "v1.2.3", ShortSHA: "abcdef1"}, want: "v1.2.3"},
+		{name: "prefixed tag", version: Version{Tag: "sub/v1.2.3", Semver: "v1.2.3", ShortSHA: "abcdef1"}, want: "v1.2.3"},
+		{name: "commits since tag", version: Version{Tag: "v1.2.3", Semver: "v1.2.3", Commits: 5, ShortSHA: "abcdef1"}, want: "v1.2.4-dev.5+gabcdef1"},
+		{name: "dirty tag", version: Version{Tag: "v1.2.3", Semver: "v1.2.3", ShortSHA: "abcdef1", Dirty: true}, want: "v1.2.4-dev.0+gabcdef1.dirty"},
+		{name: "commits and dirty", version: Version{Tag: "v1.2.3", Semver: "v1.2.3", Commits: 2, ShortSHA: "abcdef1", Dirty: true}, want: "v1.2.4-dev.2+gabcdef1.dirty"},
+		{name: "no tags", version: Version{Semver: "v0.0.0", Commits: 7, ShortSHA: "abcdef1"}, want: "v0.0.0-dev.7+gabcdef1"},
+		{name: "no tags dirty", version: Version{Semver: "v0.0.0", Commits: 7, ShortSHA: "abcdef1", Dirty: true}, want: "v0.0.0-dev.7+gabcdef1.dirty"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.version.String(); got != tc.want {
+				t.Errorf("String() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNextPatch(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "v0.0.0", want: "v0.0.0"},
+		{version: "v0.1.0", want: "v0.1.1"},
+		{version: "v1.2.3", want: "v1.2.4"},
+		{version: "v1.2.9", want: "v1.2.10"},
+		{version: "v2.0.0+incompatible", want: "v2.0.1"},
+		{version: "v1.2.3-rc.1", want: "v1.2.4"},
+		{version: "v1.2", want: "v1.2.1"},
+	}
+	for _, tc := range tests {
+		if got := nextPatch(tc.version); got != tc.want {
+			t.Errorf("nextPatch(%q) = %q, want %q", tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestParseDescribe(t *testing.T) {
+	tests := []struct {
+		name   string
+		out    string
+		prefix string
+		want   Version
+		wantOK bool
+	}{
+		{
+			name: "exact tag", out: "v1.2.3-0-gabcdef1",
+			want: Version{Tag: "v1.2.3", Semver: "v1.2.3", ShortSHA: "abcdef1"}, wantOK: true,
+		},
+		{
+			name: "commits since tag", out: "v1.2.3-12-gabcdef1",
+			want: Version{Tag: "v1.2.3", Semver: "v1.2.3", Commits: 12, ShortSHA: "abcdef1"}, wantOK: true,
+		},
+		{
+			name: "dirty", out: "v1.2.3-0-gabcdef1-dirty",
+			want: Version{Tag: "v1.2.3", Semver: "v1.2.3", ShortSHA: "abcdef1", Dirty: true}, wantOK: true,
+		},
+		{
+			name: "prerelease tag", out: "v1.2.3-rc.1-3-gabcdef1",
+			want: Version{Tag: "v1.2.3-rc.1", Semver: "v1.2.3-rc.1", Commits: 3, ShortSHA: "abcdef1"}, wantOK: true,
+		},
+		{
+			name: "prefixed tag", out: "sub/v0.4.0-2-gabcdef1-dirty", prefix: "sub/",
+			want: Version{Tag: "sub/v0.4.0", Semver: "v0.4.0", Commits: 2, ShortSHA: "abcdef1", Dirty: true}, wantOK: true,
+		},
+		{name: "not semver", out: "v1.x-0-gabcdef1"},
+		{name: "other prefix", out: "other/v1.0.0-0-gabcdef1", prefix: "sub/"},
+		{name: "no tags", out: "abcdef1"},
+		{name: "empty", out: ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := parseDescribe(tc.out, tc.prefix)
+			if ok != tc.wantOK || got != tc.want {
+				t.Errorf("parseDescribe(%q) = %+v, %v, want %+v, %v", tc.out, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestGitVersion(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir, map[string]string{"go.mod": "module example.com/root\n", "sub/go.mod": "module example.com/root/sub\n"})
+	sub := filepath.Join(dir, "sub")
+
+	check := func(t *testing.T, dir string, want *regexp.Regexp) {
+		t.Helper()
+		v, err := GitVersion(t.Context(), dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !want.MatchString(v.String()) {
+			t.Errorf("GitVersion() = %q (%+v), want match of %s", v.String(), v, want)
+		}
+	}
+
+	t.Run("no tags", func(t *testing.T) {
+		check(t, dir, regexp.MustCompile(`^v0\.0\.0-dev\.1\+g[0-9a-f]{7}$`))
+	})
+
+	// Untracked files do not make the tree dirty.
+	writeFiles(t, dir, map[string]string{"a.go": "package root\n"})
+	t.Run("no tags untracked", func(t *testing.T) {
+		check(t, dir, regexp.MustCompile(`^v0\.0\.0-dev\.1\+g[0-9a-f]{7}$`))
+	})
+
+	runGit(t, dir, "tag", "v1.0.0")
+	runGit(t, dir, "tag", "sub/v0.3.0")
+	t.Run("exact tag", func(t *testing.T) {
+		check(t, dir, regexp.MustCompile(`^v1\.0\.0$`))
+		check(t, sub, regexp.MustCompile(`^v0\.3\.0$`))
+	})
+
+	t.Run("untracked", func(t *testing.T) {
+		check(t, dir, regexp.MustCompile(`^v1\.0\.0$`))
+	})
+
+	writeFiles(t, dir, map[string]string{"go.mod": "module example.com/root\n\ngo 1.25\n"})
+	t.Run("dirty", func(t *testing.T) {
+		check(t, dir, regexp.MustCompile(`^v1\.0\.1-dev\.0\+g[0-9a-f]{7}\.dirty$`))
+	})
+
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "fix: a")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "fix: b")
+	t.Run("commits since tag", func(t *testing.T) {
+		check(t, dir, regexp.MustCompile(`^v1\.0\.1-dev\.2\+g[0-9a-f]{7}$`))
+		check(t, sub, regexp.MustCompile(`^v0\.3\.1-dev\.2\+g[0-9a-f]{7}$`))
+	})
+}
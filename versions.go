@@ -3,14 +3,22 @@ package build //nolint:revive
 const (
 	// renovate: github.com/rhysd/actionlint
 	verActionlint = "v1.7.12"
+	// renovate: golang.org/x/exp
+	verApidiff = "v0.0.0-20260908205506-85c1c2202aba"
 	// renovate: github.com/golangci/golangci-lint/v2
 	verGolangCILint = "v2.12.2"
+	// renovate: github.com/hashicorp/hcl/v2
+	verHCLFmt = "v2.25.0"
+	// renovate: github.com/golangci/misspell
+	verMisspell = "v0.8.0"
 	// renovate: github.com/wasilibs/go-prettier/v3
 	verGoPrettier = "v3.9.6"
 	// renovate: github.com/wasilibs/go-rumdl
 	verGoRumdl = "v0.2.49"
 	// renovate: github.com/wasilibs/go-ryl
 	verGoRyl = "v0.21.0"
+	// renovate: github.com/wasilibs/go-sql-formatter/v15
+	verGoSQLFormatter = "v15.6.6"
 	// renovate: github.com/wasilibs/go-shellcheck
 	verGoShellcheck = "v0.11.1"
 	// renovate: github.com/wasilibs/go-tombi
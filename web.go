@@ -0,0 +1,47 @@
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// defaultWebGlobs are the files formatted by format-web if WebGlobs is provided without globs.
+var defaultWebGlobs = []string{
+	"**/*.js", "**/*.jsx", "**/*.mjs", "**/*.cjs",
+	"**/*.ts", "**/*.tsx", "**/*.mts", "**/*.cts",
+	"**/*.css", "**/*.scss", "**/*.less",
+	"**/*.html", "**/*.vue",
+}
+
+// WebGlobs returns an Option to enable the format-web and lint-web tasks, which format
+// frontend code such as TypeScript, CSS and HTML with prettier. The globs select the files,
+// for example "web/**/*.ts". If no globs are provided, common frontend file extensions in
+// any folder are used. Files in node_modules, the artifacts path and .prettierignore are
+// excluded.
+func WebGlobs(globs ...string) Option {
+	return webGlobs{globs: globs}
+}
+
+type webGlobs struct {
+	globs []string
+}
+
+func (w webGlobs) apply(c *config) {
+	c.web = true
+	c.webGlobs = append(c.webGlobs, w.globs...)
+}
+
+// prettierWebArgs returns the prettier arguments for the web files selected by conf.
+func prettierWebArgs(conf config) string {
+	globs := conf.webGlobs
+	if len(globs) == 0 {
+		globs = defaultWebGlobs
+	}
+	args := make([]string, 0, len(globs)+2)
+	for _, g := range globs {
+		args = append(args, fmt.Sprintf("'%s'", g))
+	}
+	args = append(args, "'!**/node_modules/**'", fmt.Sprintf("'!%s/**'", filepath.ToSlash(filepath.Clean(conf.artifactsPath))))
+	return strings.Join(args, " ")
+}
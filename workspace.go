@@ -0,0 +1,258 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+)
+
+var (
+	includeModules = flag.String("include-modules", "",
+		"restrict runall and tasks run with AllModules to modules whose path or directory matches one of the `comma-separated globs`")
+	continueOnError = flag.Bool("continue-on-error", false, "runall: keep running the command in remaining modules after it fails in one")
+	excludeModules  = flag.String("exclude-modules", "",
+		"skip modules whose path or directory matches one of the `comma-separated globs` in runall and tasks run with AllModules")
+)
+
+// ModuleConcurrency returns an Option to set the maximum number of workspace modules that
+// tasks iterating over them, such as runall and download, process concurrently. If not
+// provided, the default is the number of CPUs. Use 1 to process modules sequentially.
+func ModuleConcurrency(n int) Option {
+	return moduleConcurrency(n)
+}
+
+type moduleConcurrency int
+
+func (m moduleConcurrency) apply(c *config) {
+	c.moduleConcurrency = int(m)
+}
+
+// workspaceModule is a module in the workspace as reported by `go list -m -json`.
+type workspaceModule struct {
+	Path      string
+	Dir       string
+	GoMod     string
+	GoVersion string
+}
+
+func workspaceModules(a *goyek.A) []workspaceModule {
+	a.Helper()
+	var out bytes.Buffer
+	if !cmd.Exec(a, "go list -m -json", cmd.Stdout(&out)) {
+		return nil
+	}
+	var mods []workspaceModule
+	dec := json.NewDecoder(&out)
+	for {
+		var m workspaceModule
+		if err := dec.Decode(&m); err != nil {
+			if !errors.Is(err, io.EOF) {
+				a.Errorf("failed to parse go list output: %v", err)
+			}
+			break
+		}
+		mods = append(mods, m)
+	}
+	return mods
+}
+
+// selectedModules returns the workspace modules to process, which is all of them unless
+// -affected, -include-modules or -exclude-modules is set.
+func selectedModules(a *goyek.A) []workspaceModule {
+	a.Helper()
+	var mods []workspaceModule
+	if *affectedBase == "" {
+		mods = workspaceModules(a)
+	} else {
+		mods = affectedModules(a, *affectedBase)
+	}
+	return slices.DeleteFunc(mods, func(m workspaceModule) bool {
+		if *includeModules != "" && !moduleMatches(m, *includeModules) {
+			return true
+		}
+		return *excludeModules != "" && moduleMatches(m, *excludeModules)
+	})
+}
+
+// selectedModDirs returns the directories of the modules returned by selectedModules.
+func selectedModDirs(a *goyek.A) []string {
+	a.Helper()
+	mods := selectedModules(a)
+	dirs := make([]string, len(mods))
+	for i, m := range mods {
+		dirs[i] = m.Dir
+	}
+	return dirs
+}
+
+// moduleMatches returns whether the module path or its directory relative to the current
+// directory matches any of the comma-separated globs.
+func moduleMatches(m workspaceModule, globs string) bool {
+	for g := range strings.SplitSeq(globs, ",") {
+		g = strings.TrimSpace(g)
+		if ok, _ := path.Match(g, m.Path); ok {
+			return true
+		}
+		if ok, _ := path.Match(g, moduleLabel(m.Dir)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type moduleResult struct {
+	dir    string
+	output bytes.Buffer
+	status goyek.Status
+}
+
+// forEachModule executes action for each module directory using a bounded worker pool.
+// The output of each module is buffered and printed when it completes with each line
+// prefixed by the module's directory so logs from different modules are not interleaved.
+// If failFast is set, modules that have not started are skipped after any fails. A summary
+// of passed, failed and skipped modules is printed at the end and the task fails if any
+// module failed.
+func forEachModule(a *goyek.A, conf config, dirs []string, failFast bool, action func(a *goyek.A, dir string)) {
+	a.Helper()
+
+	concurrency := conf.moduleConcurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	results := make([]*moduleResult, len(dirs))
+	sem := make(chan struct{}, concurrency)
+	var outputMu sync.Mutex
+	var wg sync.WaitGroup
+	var stopped atomic.Bool
+	for i, dir := range dirs {
+		res := &moduleResult{dir: dir}
+		results[i] = res
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if stopped.Load() {
+				res.status = goyek.StatusSkipped
+				return
+			}
+
+			r := goyek.NewRunner(func(a *goyek.A) {
+				action(a, dir)
+			})(goyek.Input{
+				Context:  a.Context(),
+				TaskName: a.Name(),
+				Output:   &res.output,
+			})
+			res.status = r.Status
+			if failFast && r.Status == goyek.StatusFailed {
+				stopped.Store(true)
+			}
+
+			outputMu.Lock()
+			defer outputMu.Unlock()
+			writePrefixed(a, moduleLabel(dir), &res.output)
+		})
+	}
+	wg.Wait()
+
+	var failed, skipped []string
+	for _, res := range results {
+		switch res.status {
+		case goyek.StatusFailed:
+			failed = append(failed, moduleLabel(res.dir))
+		case goyek.StatusSkipped:
+			skipped = append(skipped, moduleLabel(res.dir))
+		default:
+		}
+	}
+	a.Logf("%d modules passed, %d failed, %d skipped", len(results)-len(failed)-len(skipped), len(failed), len(skipped))
+	for _, s := range skipped {
+		a.Logf("SKIP: %s", s)
+	}
+	for _, f := range failed {
+		a.Errorf("FAIL: %s", f)
+	}
+}
+
+func writePrefixed(a *goyek.A, prefix string, output *bytes.Buffer) {
+	for line := range strings.Lines(output.String()) {
+		_, _ = fmt.Fprintf(a.Output(), "[%s] %s", prefix, line)
+		if !strings.HasSuffix(line, "\n") {
+			_, _ = fmt.Fprintln(a.Output())
+		}
+	}
+}
+
+// moduleLabel returns the module directory relative to the current directory for
+// display, or the directory itself if it is not relative to it.
+func moduleLabel(dir string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return dir
+	}
+	rel, err := filepath.Rel(wd, dir)
+	if err != nil {
+		return dir
+	}
+	return filepath.ToSlash(rel)
+}
+
+// moduleCoverageFile returns the name of the coverage file for the module in dir, which is
+// coverage.txt for the current module to match running without AllModules.
+func moduleCoverageFile(dir string) string {
+	label := moduleLabel(dir)
+	if label == "." {
+		return "coverage.txt"
+	}
+	return "coverage-" + strings.ReplaceAll(label, "/", "-") + ".txt"
+}
+
+// moduleField matches a reference to a field of a module in a runall command.
+var moduleField = regexp.MustCompile(`\{\{\s*\.(Dir|Path|GoVersion)\s*\}\}`)
+
+// runAll executes command in each selected module, with references to the fields of the
+// module expanded, for example {{.Dir}}.
+func runAll(a *goyek.A, conf config, command string) {
+	a.Helper()
+	mods := map[string]workspaceModule{}
+	var dirs []string
+	for _, m := range selectedModules(a) {
+		mods[m.Dir] = m
+		dirs = append(dirs, m.Dir)
+	}
+	forEachModule(a, conf, dirs, !*continueOnError, func(a *goyek.A, dir string) {
+		cmd.Exec(a, expandModuleCommand(command, mods[dir]), cmd.Dir(dir))
+	})
+}
+
+// expandModuleCommand replaces {{.Dir}}, {{.Path}} and {{.GoVersion}} in command with the
+// fields of m. Anything else in braces is kept as is, so commands with their own templates
+// such as `go list -f '{{.ImportPath}}'` are unchanged.
+func expandModuleCommand(command string, m workspaceModule) string {
+	return moduleField.ReplaceAllStringFunc(command, func(ref string) string {
+		switch moduleField.FindStringSubmatch(ref)[1] {
+		case "Dir":
+			return m.Dir
+		case "Path":
+			return m.Path
+		default:
+			return m.GoVersion
+		}
+	})
+}
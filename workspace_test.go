@@ -0,0 +1,51 @@
+package build
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestModuleMatches(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	m := workspaceModule{Path: "example.com/repo/services/api", Dir: filepath.Join(root, "services", "api")}
+
+	tests := []struct {
+		globs string
+		want  bool
+	}{
+		{globs: "example.com/repo/services/api", want: true},
+		{globs: "example.com/repo/services/*", want: true},
+		{globs: "example.com/repo/*", want: false},
+		{globs: "services/api", want: true},
+		{globs: "services/*", want: true},
+		{globs: "*", want: false},
+		{globs: "tools, services/*", want: true},
+		{globs: "tools,libs/*", want: false},
+		{globs: "[", want: false},
+	}
+	for _, tc := range tests {
+		if got := moduleMatches(m, tc.globs); got != tc.want {
+			t.Errorf("moduleMatches(%q) = %v, want %v", tc.globs, got, tc.want)
+		}
+	}
+}
+
+func TestExpandModuleCommand(t *testing.T) {
+	m := workspaceModule{Path: "example.com/repo/sub", Dir: "/src/repo/sub", GoVersion: "1.25"}
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "go test ./...", want: "go test ./..."},
+		{command: "echo {{.Dir}} {{ .Path }} go{{.GoVersion}}", want: "echo /src/repo/sub example.com/repo/sub go1.25"},
+		{command: "go list -f '{{.ImportPath}}' ./...", want: "go list -f '{{.ImportPath}}' ./..."},
+		{command: "go list -f '{{.Dir}}: {{join .GoFiles \" \"}}'", want: "go list -f '/src/repo/sub: {{join .GoFiles \" \"}}'"},
+		{command: "echo {{.Dir", want: "echo {{.Dir"},
+	}
+	for _, tc := range tests {
+		if got := expandModuleCommand(tc.command, m); got != tc.want {
+			t.Errorf("expandModuleCommand(%q) = %q, want %q", tc.command, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package build
+
+import (
+	"fmt"
+	"go/version"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goyek/goyek/v3"
+	"github.com/goyek/x/cmd"
+	"golang.org/x/mod/modfile"
+)
+
+// parsedModule is a workspace module with its parsed go.mod.
+type parsedModule struct {
+	workspaceModule
+	file *modfile.File
+}
+
+func parseWorkspaceModules(a *goyek.A) []parsedModule {
+	a.Helper()
+	var mods []parsedModule
+	for _, m := range workspaceModules(a) {
+		b, err := os.ReadFile(m.GoMod)
+		if err != nil {
+			a.Errorf("failed to read %s: %v", m.GoMod, err)
+			continue
+		}
+		f, err := modfile.Parse(m.GoMod, b, nil)
+		if err != nil {
+			a.Errorf("failed to parse %s: %v", m.GoMod, err)
+			continue
+		}
+		mods = append(mods, parsedModule{workspaceModule: m, file: f})
+	}
+	return mods
+}
+
+func lintWorkspace(a *goyek.A, root string) {
+	a.Helper()
+	// go fails to load a workspace using a directory without a go.mod, so report it clearly
+	// before listing modules.
+	goWork := filepath.Join(root, "go.work")
+	b, err := os.ReadFile(goWork)
+	if err != nil {
+		a.Errorf("failed to read %s: %v", goWork, err)
+		return
+	}
+	wf, err := modfile.ParseWork(goWork, b, nil)
+	if err != nil {
+		a.Errorf("failed to parse %s: %v", goWork, err)
+		return
+	}
+	for _, u := range wf.Use {
+		dir := u.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		if !fileExists(filepath.Join(dir, "go.mod")) {
+			a.Errorf("%s:%d: use of %s which has no go.mod, remove it with `go work edit -dropuse=%s`",
+				goWork, u.Syntax.Start.Line, u.Path, u.Path)
+		}
+	}
+	if a.Failed() {
+		return
+	}
+
+	mods := parseWorkspaceModules(a)
+	if len(mods) == 0 {
+		return
+	}
+
+	if goVersion := maxGoVersion(mods); goVersion != "" {
+		for _, m := range mods {
+			if m.file.Go != nil && m.file.Go.Version != goVersion {
+				a.Errorf("%s:%d: go directive %s differs from %s used by other modules, run format-workspace to align",
+					m.GoMod, m.file.Go.Syntax.Start.Line, m.file.Go.Version, goVersion)
+			}
+		}
+	}
+
+	isWorkspaceModule := func(path string) bool {
+		return slices.ContainsFunc(mods, func(m parsedModule) bool { return m.Path == path })
+	}
+
+	versions := map[string][]*modfile.Require{}
+	files := map[*modfile.Require]string{}
+	for _, m := range mods {
+		for _, r := range m.file.Require {
+			if isWorkspaceModule(r.Mod.Path) {
+				continue
+			}
+			versions[r.Mod.Path] = append(versions[r.Mod.Path], r)
+			files[r] = m.GoMod
+		}
+	}
+	for path, reqs := range versions {
+		if !slices.ContainsFunc(reqs, func(r *modfile.Require) bool { return r.Mod.Version != reqs[0].Mod.Version }) {
+			continue
+		}
+		var seen []string
+		for _, r := range reqs {
+			seen = append(seen, fmt.Sprintf("%s (%s:%d)", r.Mod.Version, files[r], r.Syntax.Start.Line))
+		}
+		a.Errorf("%s is required at different versions: %s, run format-workspace to sync", path, strings.Join(seen, ", "))
+	}
+
+	for _, m := range mods {
+		for _, r := range m.file.Replace {
+			if !modfile.IsDirectoryPath(r.New.Path) {
+				continue
+			}
+			dir := r.New.Path
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(m.Dir, dir)
+			}
+			switch {
+			case !fileExists(filepath.Join(dir, "go.mod")):
+				a.Errorf("%s:%d: replace of %s points to %s which has no go.mod",
+					m.GoMod, r.Syntax.Start.Line, r.Old.Path, r.New.Path)
+			case !slices.ContainsFunc(m.file.Require, func(req *modfile.Require) bool { return req.Mod.Path == r.Old.Path }):
+				a.Errorf("%s:%d: replace of %s is stale, the module is not required",
+					m.GoMod, r.Syntax.Start.Line, r.Old.Path)
+			}
+		}
+	}
+
+	for _, dir := range unusedModuleDirs(root, mods) {
+		a.Errorf("%s: module is not in go.work, add it with `go work use %s`", filepath.Join(dir, "go.mod"), dir)
+	}
+}
+
+// unusedModuleDirs returns directories under root containing a go.mod which is not used by
+// the workspace, relative to root.
+func unusedModuleDirs(root string, mods []parsedModule) []string {
+	var unused []string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // ignore unreadable directories
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				name == "testdata" || name == "vendor" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		if slices.ContainsFunc(mods, func(m parsedModule) bool { return m.Dir == dir }) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return nil //nolint:nilerr // walking under root so not possible
+		}
+		unused = append(unused, "./"+filepath.ToSlash(rel))
+		return nil
+	})
+	return unused
+}
+
+func maxGoVersion(mods []parsedModule) string {
+	res := ""
+	for _, m := range mods {
+		if m.file.Go == nil {
+			continue
+		}
+		if res == "" || version.Compare("go"+m.file.Go.Version, "go"+res) > 0 {
+			res = m.file.Go.Version
+		}
+	}
+	return res
+}
+
+func formatWorkspace(a *goyek.A, root string) {
+	a.Helper()
+	mods := parseWorkspaceModules(a)
+	if goVersion := maxGoVersion(mods); goVersion != "" {
+		for _, m := range mods {
+			if m.file.Go != nil && m.file.Go.Version != goVersion {
+				cmd.Exec(a, "go mod edit -go="+goVersion, cmd.Dir(m.Dir))
+			}
+		}
+	}
+	cmd.Exec(a, "go work sync", cmd.Dir(root))
+}
@@ -0,0 +1,88 @@
+package build
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/goyek/goyek/v3"
+)
+
+func TestLintWorkspace(t *testing.T) {
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOWORK", "")
+	t.Setenv("GOPROXY", "off")
+
+	files := func(goWork string, extra map[string]string) map[string]string {
+		res := map[string]string{
+			"go.work":  goWork,
+			"go.mod":   "module example.com/root\n\ngo 1.25\n",
+			"a/go.mod": "module example.com/root/a\n\ngo 1.25\n",
+		}
+		for k, v := range extra {
+			res[k] = v
+		}
+		return res
+	}
+	goWork := "go 1.25\n\nuse (\n\t.\n\t./a\n)\n"
+
+	tests := []struct {
+		name      string
+		files     map[string]string
+		wantError []string
+	}{
+		{
+			name:  "clean",
+			files: files(goWork, map[string]string{"testdata/go.mod": "module example.com/testdata\n"}),
+		},
+		{
+			name:      "missing module",
+			files:     files(goWork, map[string]string{"b/go.mod": "module example.com/root/b\n\ngo 1.25\n"}),
+			wantError: []string{"b/go.mod: module is not in go.work, add it with `go work use ./b`"},
+		},
+		{
+			name:      "stray module",
+			files:     files("go 1.25\n\nuse (\n\t.\n\t./a\n\t./stray\n)\n", nil),
+			wantError: []string{"go.work:6: use of ./stray which has no go.mod, remove it with `go work edit -dropuse=./stray`"},
+		},
+		{
+			name:      "go directives",
+			files:     files(goWork, map[string]string{"a/go.mod": "module example.com/root/a\n\ngo 1.24\n"}),
+			wantError: []string{"a/go.mod:3: go directive 1.24 differs from 1.25 used by other modules"},
+		},
+		{
+			name: "stale replace",
+			files: files(goWork, map[string]string{
+				"a/go.mod": "module example.com/root/a\n\ngo 1.25\n\nreplace example.com/root => ../\n",
+			}),
+			wantError: []string{"a/go.mod:5: replace of example.com/root is stale, the module is not required"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+			t.Chdir(dir)
+
+			var out bytes.Buffer
+			res := goyek.NewRunner(func(a *goyek.A) {
+				lintWorkspace(a, dir)
+			})(goyek.Input{Output: &out})
+
+			if len(tc.wantError) == 0 {
+				if res.Status != goyek.StatusPassed {
+					t.Fatalf("status = %v, want passed, output:\n%s", res.Status, out.String())
+				}
+				return
+			}
+			if res.Status != goyek.StatusFailed {
+				t.Fatalf("status = %v, want failed, output:\n%s", res.Status, out.String())
+			}
+			for _, want := range tc.wantError {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output does not contain %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}